@@ -4,12 +4,13 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 
 	libnodejs "github.com/paketo-buildpacks/libnodejs"
-	"github.com/paketo-buildpacks/libreload-packit"
+	"github.com/paketo-buildpacks/npm-start/reload"
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/fs"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 )
 
@@ -17,106 +18,698 @@ func Build(logger scribe.Emitter, reloader Reloader) packit.BuildFunc {
 	return func(context packit.BuildContext) (packit.BuildResult, error) {
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
 
-		projectPath, err := libnodejs.FindProjectPath(context.WorkingDir)
+		listedPaths, err := listedProjectPaths(context.WorkingDir)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		pkg, err := libnodejs.ParsePackageJSON(projectPath)
+		var (
+			projectPath = context.WorkingDir
+			pkg         libnodejs.PackageJSON
+			manifest    packageManifest
+		)
+
+		if len(listedPaths) == 0 {
+			projectPath, err = libnodejs.FindProjectPath(context.WorkingDir)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			pkg, err = libnodejs.ParsePackageJSON(projectPath)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			manifest, err = parsePackageManifest(projectPath)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		development := isDevelopment()
+
+		shouldLaunchWithTini, err := libnodejs.ShouldLaunchWithTini()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		validation, err := validationMode()
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		var originalProcess packit.Process
+		workspaceProcesses, err := shouldStartWorkspaceProcesses()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
 
-		shouldLaunchWithTini, err := libnodejs.ShouldLaunchWithTini()
+		multipleProcesses := workspaceProcesses || len(listedPaths) > 0
+
+		concurrent, err := concurrentScripts()
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		if shouldLaunchWithTini {
-			if projectPath != context.WorkingDir {
-				return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NODE_PROJECT_PATH")
+		killOthers, err := shouldKillOthers()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(concurrent) > 0 {
+			if shouldLaunchWithTini {
+				return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_CONCURRENT")
 			}
 
-			startParts := strings.Fields(pkg.Scripts.Start)
-			if len(startParts) == 0 {
-				return packit.BuildResult{}, fmt.Errorf("failed to parse start script %q", pkg.Scripts.Start)
+			if multipleProcesses {
+				return packit.BuildResult{}, fmt.Errorf("BP_NPM_START_CONCURRENT is not supported when several start processes are emitted")
 			}
+		}
 
-			originalProcess = packit.Process{
-				Type:    "web",
-				Command: Tini,
-				Args:    append([]string{"-g", "--"}, startParts...),
-				Default: true,
-				Direct:  true,
+		prestart, err := prestartMode()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(concurrent) > 0 && prestart != PrestartInline {
+			return packit.BuildResult{}, fmt.Errorf("BP_NPM_START_PRESTART_MODE %s is not supported with BP_NPM_START_CONCURRENT", prestart)
+		}
+
+		prestartPolicy, err := hookPolicy("prestart")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		poststartPolicy, err := hookPolicy("poststart")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if len(concurrent) > 0 && (prestartPolicy != nil || poststartPolicy != nil) {
+			return packit.BuildResult{}, fmt.Errorf("prestart and poststart timeouts and failure policies are not supported with BP_NPM_START_CONCURRENT")
+		}
+
+		if shouldLaunchWithTini && (prestartPolicy != nil || poststartPolicy != nil) {
+			return packit.BuildResult{}, fmt.Errorf("prestart and poststart timeouts and failure policies are not supported with BP_LAUNCH_WITH_TINI, which skips those scripts")
+		}
+
+		launcherSettings, wrap, err := launcherConfig()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+		launcherSettings.KillOthers = len(concurrent) > 0 && killOthers
+
+		// With JSON logs, prestart and poststart run under the launcher, so
+		// that their failures are told apart from those of the app.
+		if launcherSettings.LogFormat != "" && !shouldLaunchWithTini && len(concurrent) == 0 {
+			if prestartPolicy == nil {
+				prestartPolicy = loggedHook()
+			}
+			if poststartPolicy == nil {
+				poststartPolicy = loggedHook()
+			}
+		}
+
+		runStopScript, err := shouldRunStopScript()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if runStopScript && shouldLaunchWithTini {
+			return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_STOP_SCRIPT")
+		}
+
+		stopOrder, shutdownTimeout, err := stopSettings()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		diagnostics, err := diagnosticsSettings()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		preloads := preloadModules()
+		flags, err := startFlags()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		instrument, err := openTelemetryEnabled()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		var nodeOptions []string
+		if diagnostics != nil {
+			nodeOptions = append(nodeOptions, diagnostics.nodeOptions()...)
+		}
+		for _, module := range preloads {
+			nodeOptions = append(nodeOptions, module.option())
+		}
+		nodeOptions = append(nodeOptions, flags...)
+
+		healthArgs, healthDescription, err := healthCheck()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		restartSignalName, restartLifecycle, err := restartSignal()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		if restartLifecycle && shouldLaunchWithTini {
+			return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_RESTART_SIGNAL")
+		}
+
+		if restartLifecycle && diagnostics != nil && diagnostics.Signal == restartSignalName {
+			return packit.BuildResult{}, fmt.Errorf("BP_NODE_DIAGNOSTICS_SIGNAL and BP_NPM_START_RESTART_SIGNAL are both %s: the launcher would restart the app on it instead of relaying it for a heap snapshot", restartSignalName)
+		}
+
+		envFiles := envFilePaths(projectPath)
+
+		layer, err := context.Layers.Get("npm-start")
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		var targets []startTarget
+		switch {
+		case len(listedPaths) > 0:
+			if shouldLaunchWithTini {
+				return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_PROJECT_PATHS")
+			}
+
+			if workspaceProcesses || os.Getenv("BP_NPM_START_WORKSPACE") != "" {
+				return packit.BuildResult{}, fmt.Errorf("BP_NPM_START_PROJECT_PATHS cannot be used with BP_NPM_START_WORKSPACE or BP_NPM_START_WORKSPACE_PROCESSES")
+			}
+
+			var skipped []string
+			targets, skipped, err = projectPathTargets(context.WorkingDir, listedPaths, layer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if len(targets) == 0 {
+				return packit.BuildResult{}, fmt.Errorf("%s: none of the project paths %s has a start script", NoStartScriptError, strings.Join(listedPaths, ", "))
+			}
+
+			err = markDefaultTarget(targets, context.WorkingDir)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			logger.Process("Starting project paths")
+			for _, target := range targets {
+				logger.Subprocess("%s: %s", target.Type, target.Path)
+			}
+			for _, path := range skipped {
+				logger.Subprocess("Skipping %s: no start script", path)
+			}
+			logger.Break()
+
+		case workspaceProcesses:
+			if shouldLaunchWithTini {
+				return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_WORKSPACE_PROCESSES")
+			}
+
+			targets, err = workspaceTargets(projectPath, manifest, layer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if len(targets) == 0 {
+				return packit.BuildResult{}, fmt.Errorf("no workspace declared in %s has a start script", filepath.Join(projectPath, "package.json"))
+			}
+
+			err = markDefaultTarget(targets, projectPath)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			logger.Process("Starting workspace processes")
+			for _, target := range targets {
+				logger.Subprocess("%s: %s", target.Type, target.Path)
+			}
+			logger.Break()
+
+		default:
+			target := startTarget{
+				Type:       "web",
+				Path:       projectPath,
+				Package:    pkg,
+				Manifest:   manifest,
+				ScriptName: startScriptName(),
+				BinDirs:    []string{filepath.Join(projectPath, "node_modules", ".bin")},
+				Default:    true,
+			}
+
+			ws, err := startWorkspace(projectPath, manifest)
+			if err != nil {
+				return packit.BuildResult{}, err
 			}
 
-			logger.Process("Using tini for process launching")
-			if pkg.Scripts.PreStart != "" || pkg.Scripts.PostStart != "" {
-				logger.Subprocess("Warning: skipping prestart and/or poststart scripts because BP_LAUNCH_WITH_TINI is enabled")
+			if ws.Path != "" {
+				if shouldLaunchWithTini {
+					return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_WORKSPACE")
+				}
+
+				target.Path = ws.Path
+				target.Manifest = ws.Manifest
+				target.BinDirs = append([]string{filepath.Join(ws.Path, "node_modules", ".bin")}, target.BinDirs...)
+
+				target.Package, err = libnodejs.ParsePackageJSON(ws.Path)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+
+				logger.Process("Starting workspace %s", os.Getenv("BP_NPM_START_WORKSPACE"))
+				logger.Subprocess("Path: %s", ws.Path)
+				logger.Subprocess("Executables: %s", strings.Join(target.BinDirs, ", "))
+				logger.Break()
 			}
-		} else {
-			command := "sh"
-			arg := concatenateNpmScripts(pkg)
 
-			// Ideally we would like the lifecycle to support setting a custom working
-			// directory to run the launch process.  Until that happens we will cd in.
+			if shouldLaunchWithTini && projectPath != context.WorkingDir {
+				return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NODE_PROJECT_PATH")
+			}
 
+			target.Script = filepath.Join(context.WorkingDir, "start.sh")
 			if projectPath != context.WorkingDir {
-				arg = fmt.Sprintf("cd %s && %s", projectPath, arg)
+				target.Script = filepath.Join(projectPath, "start.sh")
 			}
 
-			/*
-				Ubuntu uses Dash as the default shell, while UBI uses Bash.
-				The version of Bash on the current UBI images does not properly handle
-				the signal handling logic added in the script. Running the command using bash -c
-				and escaping the quotes changes the behavior to match that of of running with Dash.
-				This issue is fixed in more recent versions of Bash (>=5.x), however until UBI and Ubuntu
-				begin using this version, the following workaround is necesary.
-			*/
-			etcOsReleaseFileContent, err := os.ReadFile(filepath.Join("/etc/os-release"))
-			if err == nil {
-				re := regexp.MustCompile(`ID=(rhel|"rhel")`)
+			targets = append(targets, target)
+		}
 
-				match := re.FindStringSubmatch(string(etcOsReleaseFileContent))
-				if match != nil {
-					arg = fmt.Sprintf("bash -c \"%s\"", strings.ReplaceAll(arg, `"`, `\"`))
+		if development {
+			for i, target := range targets {
+				// Like npm, run the predev and postdev scripts around dev.
+				if script, ok := developmentStartScript(target.Manifest); ok {
+					targets[i].Package.Scripts.PreStart = target.Manifest.Scripts["predev"]
+					targets[i].Package.Scripts.Start = script
+					targets[i].Package.Scripts.PostStart = target.Manifest.Scripts["postdev"]
+					targets[i].ScriptName = "dev"
 				}
 			}
+		}
 
-			script, err := createStartupScript(fmt.Sprintf(StartupScript, arg), projectPath, context.WorkingDir)
+		stopScripts := false
+		prestartHooks, poststartHooks := false, false
+		telemetry := map[string]*openTelemetry{}
+		for _, target := range targets {
+			if instrument {
+				if settings := openTelemetrySettings(target, preloads); settings != nil {
+					telemetry[target.Type] = settings
+				}
+			}
+			stopScripts = stopScripts || (runStopScript && hasStopScript(target))
+			prestartHooks = prestartHooks || (prestartPolicy != nil && prestart == PrestartInline && target.Package.Scripts.PreStart != "")
+			poststartHooks = poststartHooks || (poststartPolicy != nil && target.Package.Scripts.PostStart != "")
+		}
+
+		shouldEnableReload, err := reloader.ShouldEnableLiveReload()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+		shouldEnableReload = shouldEnableReload || development
+
+		// The node backend runs a supervisor written to the layer, through sh.
+		nodeReload := shouldEnableReload && reload.SelectedBackend() == reload.BackendNode
+		if nodeReload && shouldLaunchWithTini {
+			return packit.BuildResult{}, fmt.Errorf("BP_LAUNCH_WITH_TINI does not support yet being used with BP_LIVE_RELOAD_BACKEND=node")
+		}
+
+		needsLauncher := len(concurrent) > 0 || wrap || stopScripts || restartLifecycle || prestartHooks || poststartHooks
+		needsLayer := len(envFiles) > 0 || development || multipleProcesses || needsLauncher || prestart == PrestartProcess || healthArgs != nil || len(nodeOptions) > 0 || len(telemetry) > 0 || nodeReload
+
+		if needsLayer {
+			layer, err = layer.Reset()
 			if err != nil {
 				return packit.BuildResult{}, err
 			}
 
-			args := []string{script}
-			originalProcess = packit.Process{
-				Type:    "web",
-				Command: command,
-				Args:    args,
-				Default: true,
-				Direct:  true,
+			layer.Launch = true
+		}
+
+		if needsLauncher {
+			err = os.MkdirAll(filepath.Join(layer.Path, "bin"), os.ModePerm)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			err = fs.Copy(filepath.Join(context.CNBPath, "bin", "launcher"), filepath.Join(layer.Path, LauncherBinary))
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		if nodeReload {
+			err = reload.WriteNodeSupervisor(layer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		if healthArgs != nil {
+			err = os.MkdirAll(filepath.Join(layer.Path, "bin"), os.ModePerm)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			err = fs.Copy(filepath.Join(context.CNBPath, "bin", "health"), filepath.Join(layer.Path, HealthBinary))
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		if wrap || stopScripts || restartLifecycle || prestartHooks || poststartHooks {
+			logger.Process("Running the start process under the launcher")
+			if restart := launcherSettings.Restart; restart.Policy != "" {
+				retries := "unlimited"
+				if restart.MaxRetries > 0 {
+					retries = fmt.Sprintf("at most %d", restart.MaxRetries)
+				}
+				logger.Subprocess("Restart: %s, %s consecutive restarts, backoff starting at %s", restart.Policy, retries, restart.Backoff)
+			}
+			if launcherSettings.LogFormat != "" {
+				logger.Subprocess("Lifecycle events: logged as JSON to stderr")
+			}
+			if wait := launcherSettings.WaitFor; wait != nil {
+				logger.Subprocess("Wait for: %s, within %s", strings.Join(wait.Endpoints, ", "), wait.Timeout)
+			}
+			if stopScripts {
+				logger.Subprocess("Stop script: runs on SIGTERM %s, within %s", stopOrderDescription(stopOrder), shutdownTimeout)
+			}
+			if restartLifecycle {
+				logger.Subprocess("Restart lifecycle: runs in place on %s", restartSignalName)
+			}
+			if prestartHooks {
+				logger.Subprocess("Prestart script: %s", hookDescription(prestartPolicy))
+			}
+			if poststartHooks {
+				logger.Subprocess("Poststart script: %s", hookDescription(poststartPolicy))
+			}
+			logger.Break()
+		}
+
+		if development {
+			logger.Process("Applying development environment profile")
+			for _, target := range targets {
+				if multipleProcesses {
+					logger.Subprocess("Start script for %s: %s", target.Type, target.ScriptName)
+				} else {
+					logger.Subprocess("Start script: %s", target.ScriptName)
+				}
+			}
+			logger.Subprocess("NODE_ENV: development")
+			logger.Subprocess("Dev dependencies: node_modules required for build and launch")
+			logger.Subprocess("Live reload: enabled")
+			logger.Subprocess("Debug process: node %s", DebugInspectOption)
+			logger.Break()
+		}
+
+		if diagnostics != nil {
+			logger.Process("Enabling Node diagnostics")
+			logger.Subprocess("Reports: on fatal errors, written to %s", diagnostics.Dir)
+			logger.Subprocess("Heap snapshots: on %s and near the heap limit, written to %s", diagnostics.Signal, diagnostics.Dir)
+			logger.Subprocess("CPU profiles: written to %s when a profile process exits", diagnostics.Dir)
+			logger.Break()
+		}
+
+		if len(preloads) > 0 || len(flags) > 0 {
+			logger.Process("Configuring node options")
+			for _, module := range preloads {
+				logger.Subprocess("Preload: %s", module.option())
 			}
+			if len(flags) > 0 {
+				logger.Subprocess("Flags: %s", strings.Join(flags, " "))
+			}
+			logger.Break()
 		}
 
 		var processes []packit.Process
-		if shouldEnableReload, err := reloader.ShouldEnableLiveReload(); err != nil {
-			return packit.BuildResult{}, err
-		} else if shouldEnableReload {
-			nonReloadableProcess, reloadableProcess := reloader.TransformReloadableProcesses(originalProcess, libreload.ReloadableProcessSpec{
-				WatchPaths: []string{projectPath},
-				IgnorePaths: []string{
-					filepath.Join(projectPath, "package.json"),
-					filepath.Join(projectPath, "package-lock.json"),
-					filepath.Join(projectPath, "node_modules"),
-				},
+		for _, target := range targets {
+			var prestartProcesses []packit.Process
+			if script := target.Package.Scripts.PreStart; script != "" && prestart != PrestartInline {
+				if prestart == PrestartProcess {
+					process, err := prestartProcess(logger, target, validation, development, context.WorkingDir, layer.Path)
+					if err != nil {
+						return packit.BuildResult{}, err
+					}
+					prestartProcesses = append(prestartProcesses, process)
+
+					logger.Process("Moving the prestart script of %s to the %s process", target.Type, process.Type)
+					logger.Subprocess("Run it once, such as from a Kubernetes Job or init container, before starting %s", target.Type)
+				} else {
+					logger.Process("Skipping the prestart script of %s", target.Type)
+				}
+				logger.Subprocess("prestart: %s", script)
+				logger.Break()
+
+				target.Package.Scripts.PreStart = ""
+			}
+
+			settings := launcherSettings
+			if runStopScript {
+				settings.Stop, err = stopHook(target, stopOrder, shutdownTimeout, context.WorkingDir, layer.Path)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+			}
+
+			if restartLifecycle {
+				settings.Reload, err = reloadHook(target, restartSignalName, context.WorkingDir, layer.Path)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+			}
+
+			// Hooks with a timeout or failure policy run under the launcher
+			// rather than inline in the start script.
+			pre, err := launcherHook(target, "prestart", target.Package.Scripts.PreStart, prestartPolicy, context.WorkingDir, layer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			if pre != nil {
+				target.Package.Scripts.PreStart = ""
+			}
+
+			post, err := launcherHook(target, "poststart", target.Package.Scripts.PostStart, poststartPolicy, context.WorkingDir, layer.Path)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			if post != nil {
+				target.Package.Scripts.PostStart = ""
+			}
+
+			// The launcher relays signals to the app itself, so the start
+			// script only relays the heap snapshot signal without it.
+			target.Supervised = len(concurrent) > 0 || wrap || settings.Stop != nil || settings.Reload != nil || pre != nil || post != nil
+			if diagnostics != nil && !target.Supervised {
+				target.RelaySignal = diagnostics.Signal
+			}
+
+			targetPreloads := preloads
+			if settings := telemetry[target.Type]; settings != nil {
+				if multipleProcesses {
+					logger.Process("Enabling OpenTelemetry auto-instrumentation for %s", target.Type)
+				} else {
+					logger.Process("Enabling OpenTelemetry auto-instrumentation")
+				}
+				logger.Subprocess("Preload: %s", settings.module().option())
+				if settings.ServiceName != "" {
+					logger.Subprocess("Service: %s", settings.description())
+				}
+				logger.Break()
+
+				targetPreloads = append(slices.Clip(preloads), settings.module())
+			}
+
+			err = reportProblems(logger, validation, validatePreloads(targetPreloads, target, development), nil)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			first := len(processes)
+
+			var originalProcess packit.Process
+			if len(concurrent) > 0 {
+				logger.Process("Running npm scripts concurrently")
+				for _, script := range concurrent {
+					logger.Subprocess("%s: %s", script.Name, script.Script)
+				}
+				logger.Subprocess("Stop the others when one exits: %t", killOthers)
+				logger.Break()
+
+				originalProcess, err = concurrentProcess(logger, target, concurrent, settings, validation, development, context.WorkingDir, layer.Path)
+			} else {
+				originalProcess, err = startProcess(logger, target, shouldLaunchWithTini, validation, development, context.WorkingDir)
+				if err == nil && target.Supervised {
+					originalProcess, err = launcherProcess(originalProcess, target.ScriptName, pre, post, settings, layer.Path)
+				}
+			}
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			if !shouldEnableReload {
+				processes = append(processes, originalProcess)
+			} else {
+				// With several processes, each one only watches its own
+				// package.
+				reloadPath := projectPath
+				if multipleProcesses {
+					reloadPath = target.Path
+				}
+
+				spec, err := reloadableProcessSpec(reloadPath)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+				if nodeReload {
+					spec.LayerPath = layer.Path
+				}
+
+				if multipleProcesses {
+					logger.Process("Configuring live reload for %s", target.Type)
+				} else {
+					logger.Process("Configuring live reload")
+				}
+				logger.Subprocess("Watching: %s", strings.Join(spec.WatchPaths, ", "))
+				if len(spec.Extensions) > 0 {
+					logger.Subprocess("Extensions: %s", strings.Join(spec.Extensions, ", "))
+				}
+				if spec.SignalOnly {
+					logger.Subprocess("Mode: signal the running process")
+				}
+				if spec.Signal != "" {
+					logger.Subprocess("Signal: %s", spec.Signal)
+				}
+				if spec.Debounce > 0 {
+					logger.Subprocess("Debounce: %s", spec.Debounce)
+				}
+				if len(spec.IgnorePaths) > 0 {
+					logger.Subprocess("Ignoring:")
+					for _, path := range spec.IgnorePaths {
+						logger.Action(path)
+					}
+				}
+
+				nonReloadableProcess, reloadableProcess := reloader.TransformReloadableProcesses(originalProcess, spec)
+
+				reinstall, err := shouldReinstallDependencies()
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+
+				if reinstall {
+					if shouldLaunchWithTini {
+						return packit.BuildResult{}, fmt.Errorf("BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES requires npm at launch and is not supported with BP_LAUNCH_WITH_TINI")
+					}
+
+					if multipleProcesses {
+						return packit.BuildResult{}, fmt.Errorf("BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES is not supported when several start processes are emitted")
+					}
+
+					logger.Subprocess("Reinstalling dependencies when package.json or package-lock.json change")
+
+					script, err := createReinstallScript(projectPath, reloadableProcess)
+					if err != nil {
+						return packit.BuildResult{}, err
+					}
+
+					manifest := manifestSpec(projectPath)
+					if nodeReload {
+						manifest.LayerPath = layer.Path
+					}
+
+					_, reloadableProcess = reloader.TransformReloadableProcesses(packit.Process{
+						Type:    reloadableProcess.Type,
+						Command: "sh",
+						Args:    []string{script},
+						Default: true,
+						Direct:  true,
+					}, manifest)
+				}
+				logger.Break()
+
+				nonReloadableProcess.Type = variantType(target.Type, "no-reload")
+				reloadableProcess.Type = target.Type
+				processes = append(processes, reloadableProcess, nonReloadableProcess)
+			}
+
+			if development {
+				debugProcess := originalProcess
+				debugProcess.Type = variantType(target.Type, "debug")
+				debugProcess.Default = false
+				processes = append(processes, debugProcess)
+			}
+
+			if diagnostics != nil {
+				profileProcess := originalProcess
+				profileProcess.Type = variantType(target.Type, "profile")
+				profileProcess.Default = false
+				processes = append(processes, profileProcess)
+			}
+
+			if settings := telemetry[target.Type]; settings != nil {
+				for _, process := range processes[first:] {
+					settings.configure(processEnv(layer, process.Type))
+				}
+			}
+
+			processes = append(processes, prestartProcesses...)
+		}
+
+		if healthArgs != nil {
+			logger.Process("Adding the health process")
+			logger.Subprocess("Probe: %s", healthDescription)
+			logger.Break()
+
+			processes = append(processes, packit.Process{
+				Type:    "health",
+				Command: filepath.Join(layer.Path, HealthBinary),
+				Args:    healthArgs,
+				Direct:  true,
 			})
-			nonReloadableProcess.Type = "no-reload"
-			reloadableProcess.Type = "web"
-			processes = append(processes, reloadableProcess, nonReloadableProcess)
-		} else {
-			processes = append(processes, originalProcess)
+		}
+
+		if len(envFiles) > 0 {
+			logger.Process("Loading env files at launch")
+			for _, path := range envFiles {
+				logger.Subprocess(path)
+				if _, err := os.Stat(path); err != nil {
+					logger.Subprocess("Warning: %s does not exist at build time and will only be loaded if present at launch", path)
+				}
+			}
+			logger.Break()
+
+			layer.LaunchEnv.Default("NPM_START_ENV_FILES", strings.Join(envFiles, string(os.PathListSeparator)))
+			layer.ExecD = []string{filepath.Join(context.CNBPath, "bin", "env-file")}
+		}
+
+		if development {
+			layer.LaunchEnv.Default("NODE_ENV", EnvironmentDevelopment)
+			for _, target := range targets {
+				debugType := variantType(target.Type, "debug")
+				appendNodeOptions(processEnv(layer, debugType), DebugInspectOption)
+			}
+		}
+
+		if len(nodeOptions) > 0 {
+			layer.LaunchEnv.Append("NODE_OPTIONS", strings.Join(nodeOptions, " "), " ")
+		}
+
+		if diagnostics != nil {
+			for _, target := range targets {
+				profileType := variantType(target.Type, "profile")
+				appendNodeOptions(processEnv(layer, profileType), CPUProfileOption)
+			}
+		}
+
+		var layers []packit.Layer
+		if needsLayer {
+			layers = append(layers, layer)
 		}
 
 		logger.LaunchProcesses(processes)
@@ -125,38 +718,10 @@ func Build(logger scribe.Emitter, reloader Reloader) packit.BuildFunc {
 			Plan: packit.BuildpackPlan{
 				Entries: []packit.BuildpackPlanEntry{},
 			},
+			Layers: layers,
 			Launch: packit.LaunchMetadata{
 				Processes: processes,
 			},
 		}, nil
 	}
 }
-
-func createStartupScript(script, projectPath, workingDir string) (string, error) {
-	targetDir := workingDir
-	if projectPath != workingDir {
-		targetDir = projectPath
-	}
-
-	path := filepath.Join(targetDir, "start.sh")
-	err := os.WriteFile(path, []byte(script), 0644)
-	if err != nil {
-		return "", err
-	}
-
-	return path, nil
-}
-
-func concatenateNpmScripts(pkg libnodejs.PackageJSON) string {
-	arg := fmt.Sprintf("%s $@", pkg.Scripts.Start)
-
-	if pkg.Scripts.PreStart != "" {
-		arg = fmt.Sprintf("%s && %s", pkg.Scripts.PreStart, arg)
-	}
-
-	if pkg.Scripts.PostStart != "" {
-		arg = fmt.Sprintf("%s && %s", arg, pkg.Scripts.PostStart)
-	}
-
-	return arg
-}
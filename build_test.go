@@ -6,12 +6,15 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
-	"github.com/paketo-buildpacks/libreload-packit"
 	npmstart "github.com/paketo-buildpacks/npm-start"
 	"github.com/paketo-buildpacks/npm-start/fakes"
+	"github.com/paketo-buildpacks/npm-start/launcher"
 	"github.com/paketo-buildpacks/npm-start/matchers"
+	"github.com/paketo-buildpacks/npm-start/reload"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/scribe"
 	"github.com/sclevine/spec"
@@ -211,11 +214,16 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 				Args:    []string{startScript},
 			}))
 
-			Expect(reloader.TransformReloadableProcessesCall.Receives.Spec).To(Equal(libreload.ReloadableProcessSpec{
+			Expect(reloader.TransformReloadableProcessesCall.Receives.Spec).To(Equal(reload.ReloadableProcessSpec{
 				IgnorePaths: []string{
 					filepath.Join(workingDir, "some-project-dir", "package.json"),
 					filepath.Join(workingDir, "some-project-dir", "package-lock.json"),
+					filepath.Join(workingDir, "some-project-dir", "npm-shrinkwrap.json"),
+					filepath.Join(workingDir, "some-project-dir", "yarn.lock"),
+					filepath.Join(workingDir, "some-project-dir", "pnpm-lock.yaml"),
 					filepath.Join(workingDir, "some-project-dir", "node_modules"),
+					filepath.Join(workingDir, "some-project-dir", "start.sh"),
+					filepath.Join(workingDir, "some-project-dir", "reinstall.sh"),
 				},
 				WatchPaths: []string{filepath.Join(workingDir, "some-project-dir")},
 			}))
@@ -230,6 +238,371 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 
 			Expect(startScript).To(matchers.BeAFileWithSubstring("some-prestart-command && some-start-command $@ && some-poststart-command"))
 
+			Expect(buffer.String()).To(ContainSubstring("Configuring live reload"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Watching: %s", filepath.Join(workingDir, "some-project-dir"))))
+		})
+
+		context("when the project has .gitignore and .dockerignore files", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", ".gitignore"), []byte(`# build output
+dist/
+/coverage
+*.log
+.cache/
+config/local.json
+!keep.log
+node_modules
+`), 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", ".dockerignore"), []byte(`tmp
+**/*.swp
+`), 0600)).To(Succeed())
+			})
+
+			it("ignores the patterns they contain and logs the resolved list", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				projectDir := filepath.Join(workingDir, "some-project-dir")
+				Expect(reloader.TransformReloadableProcessesCall.Receives.Spec.IgnorePaths).To(Equal([]string{
+					filepath.Join(projectDir, "package.json"),
+					filepath.Join(projectDir, "package-lock.json"),
+					filepath.Join(projectDir, "npm-shrinkwrap.json"),
+					filepath.Join(projectDir, "yarn.lock"),
+					filepath.Join(projectDir, "pnpm-lock.yaml"),
+					filepath.Join(projectDir, "node_modules"),
+					filepath.Join(projectDir, "start.sh"),
+					filepath.Join(projectDir, "reinstall.sh"),
+					filepath.Join(projectDir, "**", "dist"),
+					filepath.Join(projectDir, "coverage"),
+					filepath.Join(projectDir, "**", "*.log"),
+					filepath.Join(projectDir, "**", ".cache"),
+					filepath.Join(projectDir, "config", "local.json"),
+					filepath.Join(projectDir, "**", "node_modules"),
+					filepath.Join(projectDir, "tmp"),
+					filepath.Join(projectDir, "**", "*.swp"),
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Ignoring:"))
+				Expect(buffer.String()).To(ContainSubstring(filepath.Join(projectDir, "**", "dist")))
+				Expect(buffer.String()).To(ContainSubstring(filepath.Join(projectDir, "yarn.lock")))
+			})
+		})
+
+		context("when watch paths, ignore paths and extensions are configured", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "src"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "views"), os.ModePerm)).To(Succeed())
+
+				t.Setenv("BP_LIVE_RELOAD_WATCH_PATHS", "src, views")
+				t.Setenv("BP_LIVE_RELOAD_IGNORE_PATHS", "logs,uploads/tmp")
+				t.Setenv("BP_LIVE_RELOAD_EXTENSIONS", ".js,ts,hbs")
+			})
+
+			it("passes them through to the reloader", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(reloader.TransformReloadableProcessesCall.Receives.Spec).To(Equal(reload.ReloadableProcessSpec{
+					WatchPaths: []string{
+						filepath.Join(workingDir, "some-project-dir", "src"),
+						filepath.Join(workingDir, "some-project-dir", "views"),
+					},
+					IgnorePaths: []string{
+						filepath.Join(workingDir, "some-project-dir", "package.json"),
+						filepath.Join(workingDir, "some-project-dir", "package-lock.json"),
+						filepath.Join(workingDir, "some-project-dir", "npm-shrinkwrap.json"),
+						filepath.Join(workingDir, "some-project-dir", "yarn.lock"),
+						filepath.Join(workingDir, "some-project-dir", "pnpm-lock.yaml"),
+						filepath.Join(workingDir, "some-project-dir", "node_modules"),
+						filepath.Join(workingDir, "some-project-dir", "start.sh"),
+						filepath.Join(workingDir, "some-project-dir", "reinstall.sh"),
+						filepath.Join(workingDir, "some-project-dir", "logs"),
+						filepath.Join(workingDir, "some-project-dir", "uploads", "tmp"),
+					},
+					Extensions: []string{"js", "ts", "hbs"},
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Extensions: js, ts, hbs"))
+			})
+
+			context("when a watch path does not exist", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_WATCH_PATHS", "src,lib")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(fmt.Sprintf("failed to parse BP_LIVE_RELOAD_WATCH_PATHS: watch path %s does not exist", filepath.Join(workingDir, "some-project-dir", "lib"))))
+				})
+			})
+
+			context("when an ignore path is outside of the project path", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_IGNORE_PATHS", "../elsewhere")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse BP_LIVE_RELOAD_IGNORE_PATHS: ../elsewhere is outside of the project path")))
+				})
+			})
+
+			context("when an extension is invalid", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_EXTENSIONS", "js,*.ts")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(`failed to parse BP_LIVE_RELOAD_EXTENSIONS: "*.ts" is not a valid file extension`))
+				})
+			})
+		})
+
+		context("when the restart signal, debounce and mode are configured", func() {
+			it.Before(func() {
+				t.Setenv("BP_LIVE_RELOAD_SIGNAL", "usr2")
+				t.Setenv("BP_LIVE_RELOAD_DEBOUNCE", "750ms")
+				t.Setenv("BP_LIVE_RELOAD_MODE", "signal")
+			})
+
+			it("threads them through to the reloader", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				spec := reloader.TransformReloadableProcessesCall.Receives.Spec
+				Expect(spec.Signal).To(Equal("SIGUSR2"))
+				Expect(spec.Debounce).To(Equal(750 * time.Millisecond))
+				Expect(spec.SignalOnly).To(BeTrue())
+
+				Expect(buffer.String()).To(ContainSubstring("Mode: signal the running process"))
+				Expect(buffer.String()).To(ContainSubstring("Signal: SIGUSR2"))
+				Expect(buffer.String()).To(ContainSubstring("Debounce: 750ms"))
+			})
+
+			context("when the signal is unknown", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_SIGNAL", "SIGWINCH")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to parse BP_LIVE_RELOAD_SIGNAL value SIGWINCH: must be one of SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1, SIGUSR2"))
+				})
+			})
+
+			context("when the debounce is not a duration", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_DEBOUNCE", "500")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to parse BP_LIVE_RELOAD_DEBOUNCE value 500: must be a positive duration such as 500ms"))
+				})
+			})
+
+			context("when the mode is unknown", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_MODE", "hot-swap")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to parse BP_LIVE_RELOAD_MODE value hot-swap: must be one of restart or signal"))
+				})
+			})
+		})
+
+		context("when BP_LIVE_RELOAD_BACKEND is node", func() {
+			var projectDir string
+
+			it.Before(func() {
+				t.Setenv("BP_LIVE_RELOAD_BACKEND", "node")
+
+				projectDir = filepath.Join(workingDir, "some-project-dir")
+				Expect(os.MkdirAll(filepath.Join(projectDir, "src"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(projectDir, "dist"), os.ModePerm)).To(Succeed())
+				Expect(os.MkdirAll(filepath.Join(projectDir, "node_modules"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(projectDir, "server.js"), nil, 0600)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(projectDir, ".gitignore"), []byte("dist\n"), 0600)).To(Succeed())
+			})
+
+			it("watches the top-level entries that are not ignored and writes the supervisor to the layer", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				layerPath := filepath.Join(layersDir, "npm-start")
+				Expect(reloader.TransformReloadableProcessesCall.Receives.Spec).To(Equal(reload.ReloadableProcessSpec{
+					WatchPaths: []string{
+						filepath.Join(projectDir, ".gitignore"),
+						filepath.Join(projectDir, "server.js"),
+						filepath.Join(projectDir, "src"),
+					},
+					LayerPath: layerPath,
+				}))
+
+				Expect(result.Layers).To(HaveLen(1))
+				Expect(result.Layers[0].Launch).To(BeTrue())
+				Expect(filepath.Join(layerPath, reload.NodeSupervisorFile)).To(BeAnExistingFile())
+
+				Expect(buffer.String()).NotTo(ContainSubstring("Ignoring:"))
+			})
+
+			context("when the watch paths are configured", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_WATCH_PATHS", "src")
+				})
+
+				it("watches them as they are", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+
+					Expect(reloader.TransformReloadableProcessesCall.Receives.Spec.WatchPaths).To(Equal([]string{filepath.Join(projectDir, "src")}))
+				})
+			})
+
+			context("when ignore paths are configured", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_IGNORE_PATHS", "logs")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_LIVE_RELOAD_IGNORE_PATHS is not supported with BP_LIVE_RELOAD_BACKEND=node, which watches every file in the watch paths"))
+				})
+			})
+
+			context("when extensions are configured", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_EXTENSIONS", "js")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_LIVE_RELOAD_EXTENSIONS is not supported with BP_LIVE_RELOAD_BACKEND=node, which watches every file in the watch paths"))
+				})
+			})
+
+			context("when BP_LAUNCH_WITH_TINI is true", func() {
+				it.Before(func() {
+					t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+					t.Setenv("BP_NODE_PROJECT_PATH", "")
+					Expect(os.WriteFile(filepath.Join(workingDir, "package.json"), []byte(`{
+						"scripts": {
+							"start": "node server.js"
+						}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_LAUNCH_WITH_TINI does not support yet being used with BP_LIVE_RELOAD_BACKEND=node"))
+				})
+			})
+
+			context("when a debounce is configured", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_DEBOUNCE", "500ms")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_LIVE_RELOAD_DEBOUNCE is not supported with BP_LIVE_RELOAD_BACKEND=node, which restarts on every change"))
+				})
+			})
+
+			context("when the mode is signal", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_MODE", "signal")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_LIVE_RELOAD_MODE=signal is not supported with BP_LIVE_RELOAD_BACKEND=node, which always restarts the process"))
+				})
+			})
+		})
+
+		context("when BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES is true", func() {
+			var reinstallScript string
+
+			it.Before(func() {
+				t.Setenv("BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES", "true")
+				reinstallScript = filepath.Join(workingDir, "some-project-dir", "reinstall.sh")
+
+				reloader.TransformReloadableProcessesCall.Stub = func(original packit.Process, spec reload.ReloadableProcessSpec) (packit.Process, packit.Process) {
+					return packit.Process{Type: "NonReloadable", Command: "NonReloadable"}, packit.Process{
+						Type:    "Reloadable",
+						Command: "reload",
+						Args:    append([]string{original.Command}, original.Args...),
+					}
+				}
+			})
+
+			it("wraps the reloadable process in a reloadable reinstall script that watches the manifests", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(reloader.TransformReloadableProcessesCall.CallCount).To(Equal(2))
+				Expect(reloader.TransformReloadableProcessesCall.Receives.OriginalProcess).To(Equal(packit.Process{
+					Type:    "Reloadable",
+					Command: "sh",
+					Args:    []string{reinstallScript},
+					Default: true,
+					Direct:  true,
+				}))
+				Expect(reloader.TransformReloadableProcessesCall.Receives.Spec).To(Equal(reload.ReloadableProcessSpec{
+					WatchPaths: []string{
+						filepath.Join(workingDir, "some-project-dir", "package.json"),
+						filepath.Join(workingDir, "some-project-dir", "package-lock.json"),
+					},
+				}))
+
+				Expect(result.Launch.Processes).To(ConsistOf(packit.Process{
+					Type:    "web",
+					Command: "reload",
+					Args:    []string{"sh", reinstallScript},
+				}, packit.Process{
+					Type:    "no-reload",
+					Command: "NonReloadable",
+				}))
+
+				Expect(reinstallScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd '%s'", filepath.Join(workingDir, "some-project-dir"))))
+				Expect(reinstallScript).To(matchers.BeAFileWithSubstring(`install="npm ci"`))
+				Expect(reinstallScript).To(matchers.BeAFileWithSubstring(`install="npm install --no-save"`))
+				Expect(reinstallScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("exec 'reload' 'sh' '%s'", startScript)))
+
+				Expect(buffer.String()).To(ContainSubstring("Reinstalling dependencies when package.json or package-lock.json change"))
+			})
+
+			context("when BP_LAUNCH_WITH_TINI is true", func() {
+				it.Before(func() {
+					t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+					t.Setenv("BP_NODE_PROJECT_PATH", "")
+					Expect(os.WriteFile(filepath.Join(workingDir, "package.json"), []byte(`{
+						"scripts": {
+							"start": "node server.js"
+						}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES requires npm at launch and is not supported with BP_LAUNCH_WITH_TINI"))
+				})
+			})
+
+			context("when BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES is malformed", func() {
+				it.Before(func() {
+					t.Setenv("BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES", "not-a-bool")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring("failed to parse BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES value not-a-bool")))
+				})
+			})
 		})
 
 		context("when BP_LAUNCH_WITH_TINI is also true", func() {
@@ -269,6 +642,2107 @@ func testBuild(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("when the start command is validated", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"prestart": "./bin/migrate --up",
+					"start": "NODE_ENV=production node --enable-source-maps dist/sever.js",
+					"poststart": "some-missing-binary"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "bin", "migrate"), []byte("migrate"), 0644)).To(Succeed())
+		})
+
+		it("warns about every entrypoint that cannot be run by default", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring(`Warning: executable "./bin/migrate" is not executable (mode -rw-r--r--)`))
+			Expect(buffer.String()).To(ContainSubstring(`Warning: executable "./bin/migrate" has no shebang line and is not a binary`))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf(`Warning: node entrypoint "dist/sever.js" does not exist (resolved to %s)`, filepath.Join(workingDir, "some-project-dir", "dist", "sever.js"))))
+			Expect(buffer.String()).To(ContainSubstring(`Warning: executable "some-missing-binary" was not found in node_modules/.bin or on the PATH`))
+		})
+
+		context("when BP_NPM_START_VALIDATE is strict", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_VALIDATE", "strict")
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("start command validation failed:")))
+				Expect(err).To(MatchError(ContainSubstring(`node entrypoint "dist/sever.js" does not exist`)))
+			})
+
+			context("when an executable is only missing from the PATH of the build image", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+						"scripts": {
+							"start": "some-run-image-binary --serve"
+						}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("only warns", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(buffer.String()).To(ContainSubstring(`Warning: executable "some-run-image-binary" was not found in node_modules/.bin or on the PATH of the build image`))
+				})
+			})
+
+			context("when the script changes directory", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+						"scripts": {
+							"prestart": "cd $APP_DIR && node missing.js",
+							"start": "cd dist && node server.js"
+						}
+					}`), 0600)).To(Succeed())
+
+					Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "dist"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "dist", "server.js"), nil, 0644)).To(Succeed())
+				})
+
+				it("resolves the commands that follow against that directory", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(buffer.String()).NotTo(ContainSubstring("Warning"))
+				})
+
+				context("when the entrypoint is missing from that directory", func() {
+					it.Before(func() {
+						Expect(os.Remove(filepath.Join(workingDir, "some-project-dir", "dist", "server.js"))).To(Succeed())
+						Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "server.js"), nil, 0644)).To(Succeed())
+					})
+
+					it("fails the build", func() {
+						_, err := build(buildContext)
+						Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf(`node entrypoint "server.js" does not exist (resolved to %s)`, filepath.Join(workingDir, "some-project-dir", "dist", "server.js")))))
+					})
+				})
+			})
+
+			context("when every entrypoint resolves", func() {
+				it.Before(func() {
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+						"scripts": {
+							"prestart": "./bin/migrate --up",
+							"start": "node -r dotenv/config dist/server && serve -s build; echo done",
+							"poststart": "cd . && node"
+						}
+					}`), 0600)).To(Succeed())
+
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "bin", "migrate"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+					Expect(os.Chmod(filepath.Join(workingDir, "some-project-dir", "bin", "migrate"), 0755)).To(Succeed())
+					Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "dist"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "dist", "server.js"), nil, 0644)).To(Succeed())
+					Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "node_modules", ".bin"), os.ModePerm)).To(Succeed())
+					Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "node_modules", ".bin", "serve"), nil, 0755)).To(Succeed())
+				})
+
+				it("builds", func() {
+					_, err := build(buildContext)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(buffer.String()).NotTo(ContainSubstring("Warning"))
+				})
+			})
+
+			context("when BP_LAUNCH_WITH_TINI is true", func() {
+				it.Before(func() {
+					t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+					t.Setenv("BP_NODE_PROJECT_PATH", "")
+					Expect(os.WriteFile(filepath.Join(workingDir, "package.json"), []byte(`{
+						"scripts": {
+							"start": "node server.js"
+						}
+					}`), 0600)).To(Succeed())
+				})
+
+				it("validates the tini arguments", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`node entrypoint "server.js" does not exist`)))
+				})
+			})
+		})
+
+		context("when BP_NPM_START_VALIDATE is off", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_VALIDATE", "off")
+			})
+
+			it("does not validate", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).NotTo(ContainSubstring("Warning"))
+			})
+		})
+
+		context("when BP_NPM_START_VALIDATE is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_VALIDATE", "sometimes")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_VALIDATE value sometimes: must be one of strict, warn or off"))
+			})
+		})
+	})
+
+	context("when the start command uses devDependencies", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"prestart": "npx ts-node scripts/migrate.ts",
+					"start": "nodemon --watch src src/index.js",
+					"poststart": "node -r @some-scope/register server.js"
+				},
+				"dependencies": {
+					"express": "^4.0.0"
+				},
+				"devDependencies": {
+					"nodemon": "^3.0.0",
+					"@some-scope/register": "^1.0.0",
+					"ts-node-package": "^1.0.0"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "node_modules", "ts-node-package"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "node_modules", "ts-node-package", "package.json"), []byte(`{
+				"bin": {
+					"ts-node": "dist/bin.js"
+				}
+			}`), 0600)).To(Succeed())
+		})
+
+		it("warns about each binary and module with a targeted fix", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(buffer.String()).To(ContainSubstring(`Warning: start command uses binary "nodemon" from devDependency "nodemon", which is not available at launch when dev dependencies are pruned; run the app with node directly and set BP_LIVE_RELOAD_ENABLED=true to restart it on changes`))
+			Expect(buffer.String()).To(ContainSubstring(`Warning: start command uses binary "ts-node" from devDependency "ts-node-package"`))
+			Expect(buffer.String()).To(ContainSubstring(`move "ts-node-package" to dependencies`))
+			Expect(buffer.String()).To(ContainSubstring(`Warning: start command uses module "@some-scope/register" from devDependency "@some-scope/register"`))
+		})
+
+		context("when the package is also a dependency", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+					"scripts": {
+						"start": "nodemon src/index.js"
+					},
+					"dependencies": {
+						"nodemon": "^3.0.0"
+					},
+					"devDependencies": {
+						"nodemon": "^3.0.0"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("does not warn", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).NotTo(ContainSubstring("devDependency"))
+			})
+		})
+
+		context("when BP_NPM_START_VALIDATE is strict", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_VALIDATE", "strict")
+			})
+
+			it("fails the build", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring(`start command uses binary "nodemon" from devDependency "nodemon"`)))
+			})
+		})
+
+		context("when BP_LAUNCH_WITH_TINI is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+				t.Setenv("BP_NODE_PROJECT_PATH", "")
+				Expect(os.WriteFile(filepath.Join(workingDir, "package.json"), []byte(`{
+					"scripts": {
+						"start": "tsx src/index.ts"
+					},
+					"devDependencies": {
+						"tsx": "^4.0.0"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("checks the tini arguments", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(buffer.String()).To(ContainSubstring(`Warning: start command uses binary "tsx" from devDependency "tsx", which is not available at launch when dev dependencies are pruned; compile the app with tsc during the build and run the output with node`))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_ENV_FILE is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_ENV_FILE", ".env, .env.production,/some/mounted/.env")
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", ".env"), []byte("KEY=value\n"), 0600)).To(Succeed())
+		})
+
+		it("adds an exec.d executable that loads the env files at launch", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+			Expect(layer.Name).To(Equal("npm-start"))
+			Expect(layer.Path).To(Equal(filepath.Join(layersDir, "npm-start")))
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.Build).To(BeFalse())
+			Expect(layer.Cache).To(BeFalse())
+			Expect(layer.LaunchEnv).To(Equal(packit.Environment{
+				"NPM_START_ENV_FILES.default": strings.Join([]string{
+					filepath.Join(workingDir, "some-project-dir", ".env"),
+					filepath.Join(workingDir, "some-project-dir", ".env.production"),
+					"/some/mounted/.env",
+				}, ":"),
+			}))
+			Expect(layer.ExecD).To(Equal([]string{filepath.Join(cnbDir, "bin", "env-file")}))
+
+			Expect(result.Launch.Processes).To(ConsistOf(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Default: true,
+				Direct:  true,
+				Args:    []string{startScript},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Loading env files at launch"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Warning: %s does not exist at build time", filepath.Join(workingDir, "some-project-dir", ".env.production"))))
+			Expect(buffer.String()).NotTo(ContainSubstring(fmt.Sprintf("Warning: %s does not exist", filepath.Join(workingDir, "some-project-dir", ".env")+" ")))
+		})
+	})
+
+	context("when BP_NPM_START_WORKSPACE is set", func() {
+		var workspacePath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_WORKSPACE", "@acme/api")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"workspaces": ["packages/*", "tools/*"]
+			}`), 0600)).To(Succeed())
+
+			workspacePath = filepath.Join(workingDir, "some-project-dir", "packages", "api")
+			Expect(os.MkdirAll(workspacePath, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspacePath, "package.json"), []byte(`{
+				"name": "@acme/api",
+				"scripts": {
+					"start": "node server.js"
+				}
+			}`), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workspacePath, "server.js"), nil, 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "packages", "web"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "packages", "web", "package.json"), []byte(`{
+				"name": "@acme/web"
+			}`), 0600)).To(Succeed())
+		})
+
+		it("runs the workspace start script in its directory with both bin directories on the PATH", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(ConsistOf(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Default: true,
+				Direct:  true,
+				Args:    []string{startScript},
+			}))
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && export PATH=%s:%s:$PATH && node server.js $@",
+				workspacePath,
+				filepath.Join(workspacePath, "node_modules", ".bin"),
+				filepath.Join(workingDir, "some-project-dir", "node_modules", ".bin"),
+			)))
+
+			Expect(buffer.String()).To(ContainSubstring("Starting workspace @acme/api"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Path: %s", workspacePath)))
+			Expect(buffer.String()).NotTo(ContainSubstring("Warning"))
+		})
+
+		context("when the workspace is given as a path", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WORKSPACE", "packages/api")
+			})
+
+			it("resolves it relative to the project path", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(startScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && ", workspacePath)))
+			})
+		})
+
+		context("when the binaries are hoisted to the root node_modules", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workspacePath, "package.json"), []byte(`{
+					"name": "@acme/api",
+					"scripts": {
+						"start": "serve-api"
+					}
+				}`), 0600)).To(Succeed())
+
+				Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "node_modules", ".bin"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "node_modules", ".bin", "serve-api"), []byte("#!/bin/sh\n"), 0755)).To(Succeed())
+			})
+
+			it("finds them when validating", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).NotTo(ContainSubstring("Warning"))
+			})
+		})
+
+		context("when no workspace matches", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WORKSPACE", "@acme/admin")
+			})
+
+			it("returns an error listing the workspaces", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to resolve BP_NPM_START_WORKSPACE value @acme/admin: no workspace has that name or path; available workspaces: @acme/api, @acme/web"))
+			})
+		})
+
+		context("when the root package.json declares no workspaces", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("declares no workspaces")))
+			})
+		})
+
+		context("when BP_LAUNCH_WITH_TINI is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+				t.Setenv("BP_NODE_PROJECT_PATH", "")
+				Expect(os.WriteFile(filepath.Join(workingDir, "package.json"), []byte(`{
+					"workspaces": {"packages": ["some-project-dir/packages/*"]}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_WORKSPACE"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_WORKSPACE_PROCESSES is true", func() {
+		var apiPath, webPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_WORKSPACE_PROCESSES", "true")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"workspaces": ["packages/*"]
+			}`), 0600)).To(Succeed())
+
+			apiPath = filepath.Join(workingDir, "some-project-dir", "packages", "api")
+			Expect(os.MkdirAll(apiPath, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(apiPath, "package.json"), []byte(`{
+				"name": "@acme/api",
+				"scripts": {
+					"start": "some-api-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			webPath = filepath.Join(workingDir, "some-project-dir", "packages", "web")
+			Expect(os.MkdirAll(webPath, os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(webPath, "package.json"), []byte(`{
+				"name": "@acme/web",
+				"scripts": {
+					"prestart": "some-web-prestart-command",
+					"start": "some-web-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "packages", "lib"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "packages", "lib", "package.json"), []byte(`{
+				"name": "@acme/lib"
+			}`), 0600)).To(Succeed())
+		})
+
+		it("emits a process named after each workspace with a start script", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			apiScript := filepath.Join(layersDir, "npm-start", "acme-api.sh")
+			webScript := filepath.Join(layersDir, "npm-start", "acme-web.sh")
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "acme-api",
+					Command: "sh",
+					Args:    []string{apiScript},
+					Default: true,
+					Direct:  true,
+				},
+				{
+					Type:    "acme-web",
+					Command: "sh",
+					Args:    []string{webScript},
+					Direct:  true,
+				},
+			}))
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Name).To(Equal("npm-start"))
+			Expect(result.Layers[0].Launch).To(BeTrue())
+
+			Expect(apiScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && export PATH=%s:%s:$PATH && some-api-command $@",
+				apiPath,
+				filepath.Join(apiPath, "node_modules", ".bin"),
+				filepath.Join(workingDir, "some-project-dir", "node_modules", ".bin"),
+			)))
+			Expect(webScript).To(matchers.BeAFileWithSubstring("some-web-prestart-command && some-web-command $@"))
+
+			Expect(buffer.String()).To(ContainSubstring("Starting workspace processes"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("acme-web: %s", webPath)))
+		})
+
+		context("when BP_NPM_START_DEFAULT_PROCESS is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_DEFAULT_PROCESS", "@acme/web")
+			})
+
+			it("marks that process as the default", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(HaveLen(2))
+				Expect(result.Launch.Processes[0].Default).To(BeFalse())
+				Expect(result.Launch.Processes[1].Default).To(BeTrue())
+			})
+
+			context("when it does not match a process", func() {
+				it.Before(func() {
+					t.Setenv("BP_NPM_START_DEFAULT_PROCESS", "admin")
+				})
+
+				it("returns an error", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError("failed to parse BP_NPM_START_DEFAULT_PROCESS value admin: must be one of acme-api, acme-web"))
+				})
+			})
+		})
+
+		context("when live reload is enabled", func() {
+			it.Before(func() {
+				reloader.ShouldEnableLiveReloadCall.Returns.Bool = true
+				reloader.TransformReloadableProcessesCall.Stub = func(original packit.Process, spec reload.ReloadableProcessSpec) (packit.Process, packit.Process) {
+					return packit.Process{Type: original.Type, Command: "NonReloadable"}, packit.Process{Type: original.Type, Command: "Reloadable", Args: spec.WatchPaths}
+				}
+			})
+
+			it("makes every process reloadable on changes to its own package", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{Type: "acme-api", Command: "Reloadable", Args: []string{apiPath}},
+					{Type: "acme-api-no-reload", Command: "NonReloadable"},
+					{Type: "acme-web", Command: "Reloadable", Args: []string{webPath}},
+					{Type: "acme-web-no-reload", Command: "NonReloadable"},
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring("Configuring live reload for acme-web"))
+			})
+		})
+
+		context("when no workspace has a start script", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+					"workspaces": ["packages/lib"]
+				}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(fmt.Sprintf("no workspace declared in %s has a start script", filepath.Join(workingDir, "some-project-dir", "package.json"))))
+			})
+		})
+
+		context("when BP_NPM_START_WORKSPACE is also set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WORKSPACE", "@acme/api")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_NPM_START_WORKSPACE_PROCESSES cannot be used with BP_NPM_START_WORKSPACE"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_PROJECT_PATHS lists several project paths", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_PROJECT_PATHS", "api, services/admin,docs")
+			t.Setenv("BP_NODE_PROJECT_PATH", "")
+
+			for _, path := range []string{"api", "services/admin", "docs"} {
+				Expect(os.MkdirAll(filepath.Join(workingDir, path), os.ModePerm)).To(Succeed())
+			}
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "api", "package.json"), []byte(`{
+				"scripts": {
+					"start": "some-api-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "services", "admin", "package.json"), []byte(`{
+				"scripts": {
+					"prestart": "some-admin-prestart-command",
+					"start": "some-admin-command"
+				}
+			}`), 0600)).To(Succeed())
+		})
+
+		it("emits a process with its own start script for each path with a start script", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			apiScript := filepath.Join(layersDir, "npm-start", "api.sh")
+			adminScript := filepath.Join(layersDir, "npm-start", "services-admin.sh")
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "api",
+					Command: "sh",
+					Args:    []string{apiScript},
+					Default: true,
+					Direct:  true,
+				},
+				{
+					Type:    "services-admin",
+					Command: "sh",
+					Args:    []string{adminScript},
+					Direct:  true,
+				},
+			}))
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Launch).To(BeTrue())
+
+			rootBin := filepath.Join(workingDir, "node_modules", ".bin")
+			Expect(apiScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && export PATH=%s:%s:$PATH && some-api-command $@", filepath.Join(workingDir, "api"), filepath.Join(workingDir, "api", "node_modules", ".bin"), rootBin)))
+			Expect(adminScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && export PATH=%s:%s:$PATH && some-admin-prestart-command && some-admin-command $@", filepath.Join(workingDir, "services", "admin"), filepath.Join(workingDir, "services", "admin", "node_modules", ".bin"), rootBin)))
+
+			Expect(buffer.String()).To(ContainSubstring("Starting project paths"))
+			Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("Skipping %s: no start script", filepath.Join(workingDir, "docs"))))
+		})
+
+		context("when BP_NPM_START_DEFAULT_PROCESS is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_DEFAULT_PROCESS", "services/admin")
+			})
+
+			it("marks that process as the default", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes[0].Default).To(BeFalse())
+				Expect(result.Launch.Processes[1].Default).To(BeTrue())
+			})
+		})
+
+		context("when a project path does not exist", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_PROJECT_PATHS", "api,worker")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(fmt.Sprintf("failed to parse BP_NPM_START_PROJECT_PATHS: project path %s does not exist", filepath.Join(workingDir, "worker"))))
+			})
+		})
+
+		context("when BP_NODE_PROJECT_PATH is one of the project paths", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_PROJECT_PATH", "./services/admin")
+			})
+
+			it("starts every project path", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Launch.Processes).To(HaveLen(2))
+			})
+		})
+
+		context("when BP_NODE_PROJECT_PATH is not one of the project paths", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_PROJECT_PATH", "worker")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_PROJECT_PATHS: BP_NODE_PROJECT_PATH worker is not one of the listed project paths"))
+			})
+		})
+
+		context("when BP_LAUNCH_WITH_TINI is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_PROJECT_PATHS"))
+			})
+		})
+
+		context("when BP_NPM_START_WORKSPACE is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WORKSPACE", "api")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_NPM_START_PROJECT_PATHS cannot be used with BP_NPM_START_WORKSPACE or BP_NPM_START_WORKSPACE_PROCESSES"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_CONCURRENT is set", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_CONCURRENT", "web:start, worker:worker")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"prestart": "some-prestart-command",
+					"start": "some-start-command",
+					"preworker": "some-preworker-command",
+					"worker": "some-worker-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("runs the scripts side by side under the launcher", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Launch).To(BeTrue())
+			Expect(filepath.Join(layerPath, "bin", "launcher")).To(matchers.BeAFileWithSubstring("launcher"))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Script: "start", Command: []string{"sh", filepath.Join(layerPath, "web-web.sh")}},
+					{Name: "worker", Script: "worker", Command: []string{"sh", filepath.Join(layerPath, "web-worker.sh")}},
+				},
+				KillOthers: true,
+			}))
+
+			Expect(filepath.Join(layerPath, "web-web.sh")).To(matchers.BeAFileWithSubstring("some-prestart-command && some-start-command $@"))
+			Expect(filepath.Join(layerPath, "web-worker.sh")).To(matchers.BeAFileWithSubstring("some-preworker-command && some-worker-command $@"))
+
+			Expect(buffer.String()).To(ContainSubstring("Running npm scripts concurrently"))
+			Expect(buffer.String()).To(ContainSubstring("worker: worker"))
+		})
+
+		context("when BP_NPM_START_CONCURRENT_KILL_OTHERS is false", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_CONCURRENT_KILL_OTHERS", "false")
+			})
+
+			it("lets the others run when one exits", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.KillOthers).To(BeFalse())
+			})
+		})
+
+		context("when live reload is enabled", func() {
+			it.Before(func() {
+				reloader.ShouldEnableLiveReloadCall.Returns.Bool = true
+			})
+
+			it("reloads the launcher", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(reloader.TransformReloadableProcessesCall.Receives.OriginalProcess.Command).To(Equal(filepath.Join(layerPath, "bin", "launcher")))
+			})
+		})
+
+		context("when a script does not exist", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_CONCURRENT", "web:start,worker:work")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(fmt.Sprintf(`failed to parse BP_NPM_START_CONCURRENT: no script "work" in %s`, filepath.Join(workingDir, "some-project-dir", "package.json"))))
+			})
+		})
+
+		context("when a name is used twice", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_CONCURRENT", "web:start,web:worker")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(`failed to parse BP_NPM_START_CONCURRENT value web:start,web:worker: name "web" is used more than once`))
+			})
+		})
+
+		context("when BP_NPM_START_CONCURRENT_KILL_OTHERS is malformed", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_CONCURRENT_KILL_OTHERS", "sometimes")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse BP_NPM_START_CONCURRENT_KILL_OTHERS value sometimes")))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_RESTART is set", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_RESTART", "on-failure")
+			t.Setenv("BP_NPM_START_RESTART_MAX_RETRIES", "3")
+			t.Setenv("BP_NPM_START_RESTART_BACKOFF", "500ms")
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("runs the start script under the launcher with the restart policy", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+			Expect(filepath.Join(layerPath, "bin", "launcher")).To(matchers.BeAFileWithSubstring("launcher"))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Script: "start", Command: []string{"sh", startScript}},
+				},
+				Restart: launcher.RestartPolicy{
+					Policy:     "on-failure",
+					MaxRetries: 3,
+					Backoff:    500 * time.Millisecond,
+				},
+			}))
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring("some-prestart-command && some-start-command $@ && some-poststart-command"))
+
+			Expect(buffer.String()).To(ContainSubstring("Running the start process under the launcher"))
+			Expect(buffer.String()).To(ContainSubstring("Restart: on-failure, at most 3 consecutive restarts, backoff starting at 500ms"))
+		})
+
+		context("when the retries and backoff are not set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART", "always")
+				t.Setenv("BP_NPM_START_RESTART_MAX_RETRIES", "")
+				t.Setenv("BP_NPM_START_RESTART_BACKOFF", "")
+			})
+
+			it("uses the defaults", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Restart).To(Equal(launcher.RestartPolicy{
+					Policy:     "always",
+					MaxRetries: 5,
+					Backoff:    time.Second,
+				}))
+			})
+		})
+
+		context("when BP_NPM_START_RESTART is no", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART", "no")
+			})
+
+			it("uses the start script directly", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:    "web",
+						Command: "sh",
+						Args:    []string{startScript},
+						Default: true,
+						Direct:  true,
+					},
+				}))
+				Expect(result.Layers).To(BeEmpty())
+			})
+		})
+
+		context("when BP_NPM_START_RESTART is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART", "unless-stopped")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_RESTART value unless-stopped: must be one of no, on-failure or always"))
+			})
+		})
+
+		context("when BP_NPM_START_RESTART_MAX_RETRIES is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART_MAX_RETRIES", "-1")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_RESTART_MAX_RETRIES value -1: must be a non-negative integer"))
+			})
+		})
+
+		context("when BP_NPM_START_RESTART_BACKOFF is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART_BACKOFF", "1")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_RESTART_BACKOFF value 1: must be a positive duration such as 1s"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_WAIT_FOR is set", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_WAIT_FOR", "tcp://db:5432, http://auth/health")
+			t.Setenv("BP_NPM_START_WAIT_TIMEOUT", "90s")
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("waits for the endpoints under the launcher before starting", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Script: "start", Command: []string{"sh", startScript}},
+				},
+				WaitFor: &launcher.WaitFor{
+					Endpoints: []string{"tcp://db:5432", "http://auth/health"},
+					Timeout:   90 * time.Second,
+				},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Wait for: tcp://db:5432, http://auth/health, within 1m30s"))
+		})
+
+		context("when an endpoint is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WAIT_FOR", "db:5432")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_WAIT_FOR value db:5432: db:5432 must be a tcp, http or https URL"))
+			})
+		})
+
+		context("when BP_NPM_START_WAIT_TIMEOUT is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WAIT_TIMEOUT", "a while")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_WAIT_TIMEOUT value a while: must be a positive duration such as 60s"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_LOG_FORMAT is json", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_LOG_FORMAT", "json")
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("runs the start process under the launcher with JSON lifecycle logs", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{
+						Name:    "web",
+						Script:  "start",
+						Command: []string{"sh", startScript},
+						Pre: &launcher.Hook{
+							Command:   []string{"sh", filepath.Join(layerPath, "web-prestart.sh")},
+							OnFailure: "fail",
+						},
+						Post: &launcher.Hook{
+							Command:   []string{"sh", filepath.Join(layerPath, "web-poststart.sh")},
+							OnFailure: "fail",
+						},
+					},
+				},
+				LogFormat: "json",
+			}))
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring("some-start-command $@"))
+			Expect(startScript).NotTo(matchers.BeAFileWithSubstring("some-prestart-command"))
+			Expect(filepath.Join(layerPath, "web-prestart.sh")).To(matchers.BeAFileWithSubstring("some-prestart-command $@"))
+			Expect(startScript).To(matchers.BeAFileWithSubstring("trap : HUP QUIT USR1 USR2\n"))
+			Expect(startScript).To(matchers.BeAFileWithSubstring("( trap : HUP QUIT USR1 USR2; "))
+
+			Expect(buffer.String()).To(ContainSubstring("Lifecycle events: logged as JSON to stderr"))
+			Expect(buffer.String()).To(ContainSubstring("Prestart script: no timeout, fails the process on failure"))
+		})
+
+		context("when BP_NPM_START_LOG_FORMAT is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_LOG_FORMAT", "xml")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_LOG_FORMAT value xml: must be one of text or json"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_HEALTH_CHECK is set", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_HEALTH_CHECK", "http")
+			t.Setenv("BP_NPM_START_HEALTH_CHECK_PATH", "/healthz")
+			t.Setenv("BP_NPM_START_HEALTH_CHECK_TIMEOUT", "2s")
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "health"), []byte("health"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("emits a health process that probes the app over HTTP", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: "sh",
+					Args:    []string{startScript},
+					Default: true,
+					Direct:  true,
+				},
+				{
+					Type:    "health",
+					Command: filepath.Join(layerPath, "bin", "health"),
+					Args:    []string{"--path", "/healthz", "--timeout", "2s"},
+					Direct:  true,
+				},
+			}))
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Launch).To(BeTrue())
+			Expect(filepath.Join(layerPath, "bin", "health")).To(matchers.BeAFileWithSubstring("health"))
+
+			Expect(buffer.String()).To(ContainSubstring("Adding the health process"))
+			Expect(buffer.String()).To(ContainSubstring("Probe: http://127.0.0.1:$PORT/healthz, timeout 2s"))
+		})
+
+		context("when the health check is tcp on a fixed port", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_HEALTH_CHECK", "tcp")
+				t.Setenv("BP_NPM_START_HEALTH_CHECK_PORT", "3000")
+			})
+
+			it("only checks that the port accepts connections", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(ContainElement(packit.Process{
+					Type:    "health",
+					Command: filepath.Join(layerPath, "bin", "health"),
+					Args:    []string{"--port", "3000", "--tcp", "--timeout", "2s"},
+					Direct:  true,
+				}))
+				Expect(buffer.String()).To(ContainSubstring("Probe: tcp://127.0.0.1:3000, timeout 2s"))
+			})
+		})
+
+		context("when BP_NPM_START_HEALTH_CHECK is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_HEALTH_CHECK", "grpc")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_HEALTH_CHECK value grpc: must be one of http or tcp"))
+			})
+		})
+
+		context("when BP_NPM_START_HEALTH_CHECK_PATH is relative", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_HEALTH_CHECK_PATH", "healthz")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_HEALTH_CHECK_PATH value healthz: must start with /"))
+			})
+		})
+	})
+
+	context("when package.json has a stop script and BP_NPM_START_STOP_SCRIPT is true", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_STOP_SCRIPT", "true")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"start": "some-start-command",
+					"prestop": "some-prestop-command",
+					"stop": "some-stop-command",
+					"poststop": "some-poststop-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("runs the stop lifecycle on SIGTERM under the launcher", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Script: "start", Command: []string{"sh", startScript}},
+				},
+				Stop: &launcher.StopHook{
+					Command: []string{"sh", filepath.Join(layerPath, "web-stop.sh")},
+					Order:   "before",
+					Timeout: 8 * time.Second,
+				},
+			}))
+
+			Expect(filepath.Join(layerPath, "web-stop.sh")).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && some-prestop-command && some-stop-command $@ && some-poststop-command", filepath.Join(workingDir, "some-project-dir"))))
+
+			Expect(buffer.String()).To(ContainSubstring("Stop script: runs on SIGTERM before the process is signalled, within 8s"))
+		})
+
+		context("when the order and shutdown timeout are configured", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_STOP_ORDER", "after")
+				t.Setenv("BP_NPM_START_SHUTDOWN_TIMEOUT", "25s")
+			})
+
+			it("uses them", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(config.Stop.Order).To(Equal("after"))
+				Expect(config.Stop.Timeout).To(Equal(25 * time.Second))
+
+				Expect(buffer.String()).To(ContainSubstring("Stop script: runs on SIGTERM after the process has exited, within 25s"))
+			})
+		})
+
+		context("when BP_NPM_START_STOP_SCRIPT is not set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_STOP_SCRIPT", "")
+			})
+
+			it("leaves the stop script to be run by hand", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:    "web",
+						Command: "sh",
+						Args:    []string{startScript},
+						Default: true,
+						Direct:  true,
+					},
+				}))
+				Expect(result.Layers).To(BeEmpty())
+				Expect(buffer.String()).NotTo(ContainSubstring("Stop script"))
+			})
+		})
+
+		context("when BP_LAUNCH_WITH_TINI is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_STOP_SCRIPT"))
+			})
+		})
+
+		context("when BP_NPM_START_STOP_SCRIPT is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_STOP_SCRIPT", "sometimes")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse BP_NPM_START_STOP_SCRIPT value sometimes")))
+			})
+		})
+
+		context("when BP_NPM_START_STOP_ORDER is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_STOP_ORDER", "later")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_STOP_ORDER value later: must be one of before, after or parallel"))
+			})
+		})
+
+		context("when BP_NPM_START_SHUTDOWN_TIMEOUT is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_SHUTDOWN_TIMEOUT", "soon")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_SHUTDOWN_TIMEOUT value soon: must be a positive duration such as 8s"))
+			})
+		})
+	})
+
+	context("when package.json has a restart script and BP_NPM_START_RESTART_SIGNAL is set", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_RESTART_SIGNAL", "SIGHUP")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"start": "some-start-command",
+					"prerestart": "some-prerestart-command",
+					"restart": "some-restart-command",
+					"postrestart": "some-postrestart-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("runs the restart lifecycle in place on SIGHUP under the launcher", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Script: "start", Command: []string{"sh", startScript}},
+				},
+				Reload: &launcher.ReloadHook{
+					Signal:  "SIGHUP",
+					Command: []string{"sh", filepath.Join(layerPath, "web-restart.sh")},
+				},
+			}))
+
+			Expect(filepath.Join(layerPath, "web-restart.sh")).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && some-prerestart-command && some-restart-command $@ && some-postrestart-command", filepath.Join(workingDir, "some-project-dir"))))
+
+			Expect(buffer.String()).To(ContainSubstring("Restart lifecycle: runs in place on SIGHUP"))
+		})
+
+		context("when BP_NPM_START_RESTART_SIGNAL is not set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART_SIGNAL", "")
+			})
+
+			it("does not run the restart lifecycle", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Launch.Processes).To(Equal([]packit.Process{
+					{
+						Type:    "web",
+						Command: "sh",
+						Args:    []string{startScript},
+						Default: true,
+						Direct:  true,
+					},
+				}))
+				Expect(result.Layers).To(BeEmpty())
+				Expect(buffer.String()).NotTo(ContainSubstring("Restart lifecycle"))
+			})
+		})
+
+		context("when BP_LAUNCH_WITH_TINI is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_LAUNCH_WITH_TINI does not support yet being used with BP_NPM_START_RESTART_SIGNAL"))
+			})
+		})
+
+		context("when BP_NPM_START_RESTART_SIGNAL is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART_SIGNAL", "SIGWINCH")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_RESTART_SIGNAL value SIGWINCH: must be one of SIGHUP, SIGINT, SIGQUIT, SIGTERM, SIGUSR1, SIGUSR2"))
+			})
+		})
+
+		context("when BP_NPM_START_RESTART_SIGNAL is TERM", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART_SIGNAL", "TERM")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_RESTART_SIGNAL value TERM: must not be SIGTERM or SIGINT, which stop the app"))
+			})
+		})
+
+		context("when BP_NPM_START_RESTART_SIGNAL is INT", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_RESTART_SIGNAL", "SIGINT")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_RESTART_SIGNAL value SIGINT: must not be SIGTERM or SIGINT, which stop the app"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_RESTART_SIGNAL is set without a restart script", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_RESTART_SIGNAL", "usr2")
+
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"start": "some-start-command",
+					"postrestart": "some-postrestart-command"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("stops and starts the process on that signal", func() {
+			_, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config.Reload).To(Equal(&launcher.ReloadHook{
+				Signal: "SIGUSR2",
+				Post:   []string{"sh", filepath.Join(layerPath, "web-postrestart.sh")},
+			}))
+
+			Expect(filepath.Join(layerPath, "web-postrestart.sh")).To(matchers.BeAFileWithSubstring("some-postrestart-command $@"))
+		})
+	})
+
+	context("when the prestart and poststart hooks have a timeout or failure policy", func() {
+		var layerPath string
+
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_PRESTART_TIMEOUT", "30s")
+			t.Setenv("BP_NPM_START_PRESTART_ON_FAILURE", "retry")
+			t.Setenv("BP_NPM_START_PRESTART_RETRIES", "2")
+			t.Setenv("BP_NPM_START_POSTSTART_ON_FAILURE", "continue")
+
+			Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+
+			layerPath = filepath.Join(layersDir, "npm-start")
+		})
+
+		it("runs them under the launcher", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: filepath.Join(layerPath, "bin", "launcher"),
+					Args:    []string{filepath.Join(layerPath, "web.json")},
+					Default: true,
+					Direct:  true,
+				},
+			}))
+
+			config, err := launcher.Load(filepath.Join(layerPath, "web.json"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(config).To(Equal(launcher.Config{
+				Children: []launcher.Child{
+					{
+						Name:    "web",
+						Script:  "start",
+						Command: []string{"sh", startScript},
+						Pre: &launcher.Hook{
+							Command:   []string{"sh", filepath.Join(layerPath, "web-prestart.sh")},
+							Timeout:   30 * time.Second,
+							OnFailure: "retry",
+							Retries:   2,
+							Delay:     time.Second,
+						},
+						Post: &launcher.Hook{
+							Command:   []string{"sh", filepath.Join(layerPath, "web-poststart.sh")},
+							OnFailure: "continue",
+						},
+					},
+				},
+			}))
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring("some-start-command $@"))
+			Expect(startScript).NotTo(matchers.BeAFileWithSubstring("some-prestart-command"))
+			Expect(startScript).NotTo(matchers.BeAFileWithSubstring("some-poststart-command"))
+			Expect(filepath.Join(layerPath, "web-prestart.sh")).To(matchers.BeAFileWithSubstring("some-prestart-command"))
+			Expect(filepath.Join(layerPath, "web-poststart.sh")).To(matchers.BeAFileWithSubstring("some-poststart-command"))
+
+			Expect(buffer.String()).To(ContainSubstring("Prestart script: timeout 30s, retried up to 2 times on failure"))
+			Expect(buffer.String()).To(ContainSubstring("Poststart script: no timeout, continues on failure"))
+		})
+
+		context("when the failure policy is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_PRESTART_ON_FAILURE", "ignore")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_PRESTART_ON_FAILURE value ignore: must be one of fail, continue or retry"))
+			})
+		})
+
+		context("when retries are set without the retry policy", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_POSTSTART_RETRIES", "2")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_NPM_START_POSTSTART_RETRIES requires BP_NPM_START_POSTSTART_ON_FAILURE to be retry"))
+			})
+		})
+
+		context("when BP_LAUNCH_WITH_TINI is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("prestart and poststart timeouts and failure policies are not supported with BP_LAUNCH_WITH_TINI, which skips those scripts"))
+			})
+		})
+
+		context("when the timeout is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_PRESTART_TIMEOUT", "forever")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NPM_START_PRESTART_TIMEOUT value forever: must be a positive duration such as 30s"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_PRESTART_MODE is process", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_PRESTART_MODE", "process")
+		})
+
+		it("emits a prestart process and starts the app directly", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			layerPath := filepath.Join(layersDir, "npm-start")
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: "sh",
+					Args:    []string{startScript},
+					Default: true,
+					Direct:  true,
+				},
+				{
+					Type:    "prestart",
+					Command: "sh",
+					Args:    []string{filepath.Join(layerPath, "web-prestart.sh")},
+					Direct:  true,
+				},
+			}))
+
+			Expect(result.Layers).To(HaveLen(1))
+			Expect(result.Layers[0].Launch).To(BeTrue())
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring("some-start-command $@ && some-poststart-command"))
+			Expect(startScript).NotTo(matchers.BeAFileWithSubstring("some-prestart-command"))
+			Expect(filepath.Join(layerPath, "web-prestart.sh")).To(matchers.BeAFileWithSubstring(fmt.Sprintf("cd %s && some-prestart-command $@", filepath.Join(workingDir, "some-project-dir"))))
+
+			Expect(buffer.String()).To(ContainSubstring("Moving the prestart script of web to the prestart process"))
+			Expect(buffer.String()).To(ContainSubstring("prestart: some-prestart-command"))
+		})
+
+		context("when BP_NPM_START_CONCURRENT is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_CONCURRENT", "web:start")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_NPM_START_PRESTART_MODE process is not supported with BP_NPM_START_CONCURRENT"))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_PRESTART_MODE is skip", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_PRESTART_MODE", "skip")
+		})
+
+		it("does not run the prestart script", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(ConsistOf(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Args:    []string{startScript},
+				Default: true,
+				Direct:  true,
+			}))
+
+			Expect(startScript).NotTo(matchers.BeAFileWithSubstring("some-prestart-command"))
+			Expect(buffer.String()).To(ContainSubstring("Skipping the prestart script of web"))
+		})
+	})
+
+	context("when BP_NPM_START_PRESTART_MODE is invalid", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_PRESTART_MODE", "later")
+		})
+
+		it("returns an error", func() {
+			_, err := build(buildContext)
+			Expect(err).To(MatchError("failed to parse BP_NPM_START_PRESTART_MODE value later: must be one of inline, process or skip"))
+		})
+	})
+
+	context("when BP_NODE_DIAGNOSTICS is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_NODE_DIAGNOSTICS", "true")
+			t.Setenv("BP_NODE_DIAGNOSTICS_DIR", "/diagnostics")
+		})
+
+		it("enables reports and heap snapshots, relays the signal and adds a profile process", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Launch.Processes).To(Equal([]packit.Process{
+				{
+					Type:    "web",
+					Command: "sh",
+					Args:    []string{startScript},
+					Default: true,
+					Direct:  true,
+				},
+				{
+					Type:    "profile",
+					Command: "sh",
+					Args:    []string{startScript},
+					Direct:  true,
+				},
+			}))
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring("relay() { trap '' USR2; kill -USR2 0; trap relay USR2; }"))
+			Expect(startScript).To(matchers.BeAFileWithSubstring(fmt.Sprintf("( trap '' USR2; cd %s && some-prestart-command && some-start-command $@ && some-poststart-command ) &", filepath.Join(workingDir, "some-project-dir"))))
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.LaunchEnv).To(Equal(packit.Environment{
+				"NODE_OPTIONS.append": "--report-on-fatalerror --report-directory=/diagnostics --heapsnapshot-signal=SIGUSR2 --heapsnapshot-near-heap-limit=1 --diagnostic-dir=/diagnostics",
+				"NODE_OPTIONS.delim":  " ",
+			}))
+			Expect(layer.ProcessLaunchEnv).To(Equal(map[string]packit.Environment{
+				"profile": {
+					"NODE_OPTIONS.append": "--cpu-prof",
+					"NODE_OPTIONS.delim":  " ",
+				},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Enabling Node diagnostics"))
+			Expect(buffer.String()).To(ContainSubstring("Heap snapshots: on SIGUSR2 and near the heap limit, written to /diagnostics"))
+		})
+
+		context("when BP_NODE_DIAGNOSTICS_SIGNAL is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_DIAGNOSTICS_SIGNAL", "quit")
+			})
+
+			it("relays that signal", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].LaunchEnv["NODE_OPTIONS.append"]).To(ContainSubstring("--heapsnapshot-signal=SIGQUIT"))
+				Expect(startScript).To(matchers.BeAFileWithSubstring("trap relay QUIT"))
+			})
+		})
+
+		context("when the start process runs under the launcher", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_LOG_FORMAT", "json")
+
+				Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+			})
+
+			it("leaves relaying the signal to the launcher", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(startScript).To(matchers.BeAFileWithSubstring("trap : HUP QUIT USR1 USR2\n"))
+				Expect(startScript).NotTo(matchers.BeAFileWithSubstring("relay"))
+			})
+		})
+
+		context("when BP_NODE_DIAGNOSTICS_SIGNAL is the restart signal", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_DIAGNOSTICS_SIGNAL", "SIGHUP")
+				t.Setenv("BP_NPM_START_RESTART_SIGNAL", "hup")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("BP_NODE_DIAGNOSTICS_SIGNAL and BP_NPM_START_RESTART_SIGNAL are both SIGHUP: the launcher would restart the app on it instead of relaying it for a heap snapshot"))
+			})
+		})
+
+		context("when BP_NODE_DIAGNOSTICS_SIGNAL stops the app", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_DIAGNOSTICS_SIGNAL", "SIGTERM")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NODE_DIAGNOSTICS_SIGNAL value SIGTERM: must not be SIGTERM or SIGINT, which stop the app"))
+			})
+		})
+
+		context("when BP_NODE_DIAGNOSTICS_DIR is relative", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_DIAGNOSTICS_DIR", "reports")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NODE_DIAGNOSTICS_DIR value reports: must be an absolute path"))
+			})
+		})
+	})
+
+	context("when BP_NODE_DIAGNOSTICS is invalid", func() {
+		it.Before(func() {
+			t.Setenv("BP_NODE_DIAGNOSTICS", "sometimes")
+		})
+
+		it("returns an error", func() {
+			_, err := build(buildContext)
+			Expect(err).To(MatchError(ContainSubstring("failed to parse BP_NODE_DIAGNOSTICS value sometimes")))
+		})
+	})
+
+	context("when BP_NODE_PRELOAD and BP_NODE_START_FLAGS are set", func() {
+		it.Before(func() {
+			t.Setenv("BP_NODE_PRELOAD", "dotenv/config, import:./loader.mjs")
+			t.Setenv("BP_NODE_START_FLAGS", "--enable-source-maps  --max-old-space-size=512")
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "node_modules", "dotenv"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "loader.mjs"), nil, 0600)).To(Succeed())
+		})
+
+		it("adds them to NODE_OPTIONS for every process", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.LaunchEnv).To(Equal(packit.Environment{
+				"NODE_OPTIONS.append": "--require dotenv/config --import ./loader.mjs --enable-source-maps --max-old-space-size=512",
+				"NODE_OPTIONS.delim":  " ",
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Configuring node options"))
+			Expect(buffer.String()).To(ContainSubstring("Preload: --require dotenv/config"))
+			Expect(buffer.String()).To(ContainSubstring("Flags: --enable-source-maps --max-old-space-size=512"))
+			Expect(buffer.String()).NotTo(ContainSubstring("Warning: preloaded module"))
+		})
+
+		context("when BP_NODE_DIAGNOSTICS is true", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_DIAGNOSTICS", "true")
+			})
+
+			it("merges both into NODE_OPTIONS", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].LaunchEnv["NODE_OPTIONS.append"]).To(HavePrefix("--report-on-fatalerror "))
+				Expect(result.Layers[0].LaunchEnv["NODE_OPTIONS.append"]).To(HaveSuffix(" --require dotenv/config --import ./loader.mjs --enable-source-maps --max-old-space-size=512"))
+			})
+		})
+
+		context("when the preloaded modules do not resolve", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_PRELOAD", "dd-trace/init,./missing,node:process")
+			})
+
+			it("warns about each of them", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf(`Warning: preloaded module "dd-trace/init" is not installed in %s`, filepath.Join(workingDir, "some-project-dir", "node_modules", "dd-trace"))))
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf(`Warning: preloaded module "./missing" does not exist (resolved to %s)`, filepath.Join(workingDir, "some-project-dir", "missing"))))
+				Expect(buffer.String()).NotTo(ContainSubstring(`preloaded module "node:process"`))
+			})
+
+			context("when BP_NPM_START_VALIDATE is strict", func() {
+				it.Before(func() {
+					t.Setenv("BP_NPM_START_VALIDATE", "strict")
+				})
+
+				it("fails the build", func() {
+					_, err := build(buildContext)
+					Expect(err).To(MatchError(ContainSubstring(`preloaded module "dd-trace/init" is not installed`)))
+				})
+			})
+		})
+
+		context("when a preloaded module is only a devDependency", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+					"scripts": {
+						"start": "node server.js"
+					},
+					"devDependencies": {
+						"dotenv": "^16.0.0"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("warns about it", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(`Warning: start command uses module "dotenv/config" from devDependency "dotenv"`))
+			})
+		})
+
+		context("when BP_NODE_START_FLAGS holds a value that is not a flag", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_START_FLAGS", "--max-old-space-size 512")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError("failed to parse BP_NODE_START_FLAGS value --max-old-space-size 512: 512 is not a flag; pass values as --flag=value"))
+			})
+		})
+	})
+
+	context("when the auto-instrumentations for OpenTelemetry are a dependency", func() {
+		it.Before(func() {
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"name": "checkout",
+				"version": "1.4.0",
+				"scripts": {
+					"start": "node server.js"
+				},
+				"dependencies": {
+					"@opentelemetry/auto-instrumentations-node": "^0.50.0"
+				}
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "some-project-dir", "node_modules", "@opentelemetry", "auto-instrumentations-node"), os.ModePerm)).To(Succeed())
+		})
+
+		it("preloads the register module and names the service for the start process", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.LaunchEnv).To(BeEmpty())
+			Expect(layer.ProcessLaunchEnv).To(Equal(map[string]packit.Environment{
+				"web": {
+					"NODE_OPTIONS.append":              "--require @opentelemetry/auto-instrumentations-node/register",
+					"NODE_OPTIONS.delim":               " ",
+					"OTEL_SERVICE_NAME.default":        "checkout",
+					"OTEL_RESOURCE_ATTRIBUTES.default": "service.version=1.4.0",
+				},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Enabling OpenTelemetry auto-instrumentation"))
+			Expect(buffer.String()).To(ContainSubstring("Preload: --require @opentelemetry/auto-instrumentations-node/register"))
+			Expect(buffer.String()).To(ContainSubstring("Service: checkout 1.4.0"))
+			Expect(buffer.String()).NotTo(ContainSubstring("Warning: preloaded module"))
+		})
+
+		context("when BP_ENVIRONMENT is development", func() {
+			it.Before(func() {
+				t.Setenv("BP_ENVIRONMENT", "development")
+			})
+
+			it("instruments the debug process as well", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				env := result.Layers[0].ProcessLaunchEnv
+				Expect(env["debug"]["NODE_OPTIONS.append"]).To(Equal("--require @opentelemetry/auto-instrumentations-node/register --inspect=0.0.0.0:9229"))
+				Expect(env["debug"]["OTEL_SERVICE_NAME.default"]).To(Equal("checkout"))
+			})
+		})
+
+		context("when the register module is already preloaded", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_PRELOAD", "@opentelemetry/auto-instrumentations-node/register")
+			})
+
+			it("does not preload it twice", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers[0].LaunchEnv["NODE_OPTIONS.append"]).To(Equal("--require @opentelemetry/auto-instrumentations-node/register"))
+				Expect(result.Layers[0].ProcessLaunchEnv).To(BeEmpty())
+				Expect(buffer.String()).NotTo(ContainSubstring("Enabling OpenTelemetry"))
+			})
+		})
+
+		context("when the package is not installed", func() {
+			it.Before(func() {
+				Expect(os.RemoveAll(filepath.Join(workingDir, "some-project-dir", "node_modules", "@opentelemetry"))).To(Succeed())
+			})
+
+			it("warns about it", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(buffer.String()).To(ContainSubstring(`Warning: preloaded module "@opentelemetry/auto-instrumentations-node/register" is not installed`))
+			})
+		})
+
+		context("when BP_NODE_OPENTELEMETRY is false", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_OPENTELEMETRY", "false")
+			})
+
+			it("does not instrument the app", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(result.Layers).To(BeEmpty())
+				Expect(buffer.String()).NotTo(ContainSubstring("OpenTelemetry"))
+			})
+		})
+
+		context("when BP_NODE_OPENTELEMETRY is invalid", func() {
+			it.Before(func() {
+				t.Setenv("BP_NODE_OPENTELEMETRY", "maybe")
+			})
+
+			it("returns an error", func() {
+				_, err := build(buildContext)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse BP_NODE_OPENTELEMETRY value maybe")))
+			})
+		})
+	})
+
+	context("when BP_ENVIRONMENT is development", func() {
+		it.Before(func() {
+			t.Setenv("BP_ENVIRONMENT", "development")
+			Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+				"scripts": {
+					"start": "node server.js",
+					"dev": "nodemon server.js"
+				},
+				"devDependencies": {
+					"nodemon": "^3.0.0"
+				}
+			}`), 0600)).To(Succeed())
+
+			reloader.TransformReloadableProcessesCall.Returns.Reloadable = packit.Process{
+				Type:    "Reloadable",
+				Command: "Reloadable",
+			}
+			reloader.TransformReloadableProcessesCall.Returns.NonReloadable = packit.Process{
+				Type:    "NonReloadable",
+				Command: "NonReloadable",
+			}
+		})
+
+		it("starts the dev script with live reload, NODE_ENV=development and a debug process", func() {
+			result, err := build(buildContext)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(startScript).To(matchers.BeAFileWithSubstring("nodemon server.js $@"))
+
+			Expect(result.Launch.Processes).To(ConsistOf(
+				packit.Process{
+					Type:    "web",
+					Command: "Reloadable",
+				},
+				packit.Process{
+					Type:    "no-reload",
+					Command: "NonReloadable",
+				},
+				packit.Process{
+					Type:    "debug",
+					Command: "sh",
+					Direct:  true,
+					Args:    []string{startScript},
+				},
+			))
+
+			Expect(result.Layers).To(HaveLen(1))
+			layer := result.Layers[0]
+			Expect(layer.Name).To(Equal("npm-start"))
+			Expect(layer.Launch).To(BeTrue())
+			Expect(layer.ExecD).To(BeEmpty())
+			Expect(layer.LaunchEnv).To(Equal(packit.Environment{
+				"NODE_ENV.default": "development",
+			}))
+			Expect(layer.ProcessLaunchEnv).To(Equal(map[string]packit.Environment{
+				"debug": {
+					"NODE_OPTIONS.append": "--inspect=0.0.0.0:9229",
+					"NODE_OPTIONS.delim":  " ",
+				},
+			}))
+
+			Expect(buffer.String()).To(ContainSubstring("Applying development environment profile"))
+			Expect(buffer.String()).To(ContainSubstring("Start script: dev"))
+			Expect(buffer.String()).To(ContainSubstring("NODE_ENV: development"))
+			Expect(buffer.String()).To(ContainSubstring("Dev dependencies: node_modules required for build and launch"))
+			Expect(buffer.String()).To(ContainSubstring("Configuring live reload"))
+			Expect(buffer.String()).NotTo(ContainSubstring("devDependency"))
+		})
+
+		context("when there are start and dev lifecycle scripts", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+					"scripts": {
+						"prestart": "node migrate.js",
+						"start": "node server.js",
+						"poststart": "node announce.js",
+						"predev": "node seed.js",
+						"dev": "nodemon server.js"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("runs predev and postdev around the dev script", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(startScript).To(matchers.BeAFileWithSubstring("node seed.js && nodemon server.js $@"))
+				Expect(startScript).NotTo(matchers.BeAFileWithSubstring("migrate.js"))
+				Expect(startScript).NotTo(matchers.BeAFileWithSubstring("announce.js"))
+			})
+		})
+
+		context("when only the dev script has a prestart script and a prestart policy is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_PRESTART_TIMEOUT", "30s")
+				Expect(os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
+					"scripts": {
+						"start": "node server.js",
+						"predev": "node seed.js",
+						"dev": "nodemon server.js"
+					}
+				}`), 0600)).To(Succeed())
+
+				Expect(os.MkdirAll(filepath.Join(cnbDir, "bin"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(cnbDir, "bin", "launcher"), []byte("launcher"), 0755)).To(Succeed())
+			})
+
+			it("runs the predev script under the launcher", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				layerPath := filepath.Join(layersDir, "npm-start")
+				Expect(filepath.Join(layerPath, "bin", "launcher")).To(matchers.BeAFileWithSubstring("launcher"))
+				Expect(reloader.TransformReloadableProcessesCall.Receives.OriginalProcess.Command).To(Equal(filepath.Join(layerPath, "bin", "launcher")))
+				Expect(buffer.String()).To(ContainSubstring("Prestart script:"))
+			})
+		})
+
+		context("when BP_NPM_START_SCRIPT is set", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_SCRIPT", "start")
+			})
+
+			it("starts the configured script", func() {
+				_, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(startScript).To(matchers.BeAFileWithSubstring("node server.js $@"))
+				Expect(buffer.String()).To(ContainSubstring("Start script: start"))
+			})
+		})
+
+		context("when BP_ENVIRONMENT is neither production nor development", func() {
+			it.Before(func() {
+				t.Setenv("BP_ENVIRONMENT", "staging")
+			})
+
+			it("treats it as production", func() {
+				result, err := build(buildContext)
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(startScript).To(matchers.BeAFileWithSubstring("node server.js $@"))
+				Expect(result.Layers).To(BeEmpty())
+				Expect(buffer.String()).NotTo(ContainSubstring("Applying development environment profile"))
+			})
+		})
+	})
+
 	context("when there is no prestart script", func() {
 		it.Before(func() {
 			err := os.WriteFile(filepath.Join(workingDir, "some-project-dir", "package.json"), []byte(`{
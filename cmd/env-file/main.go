@@ -0,0 +1,27 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/npm-start/envfile"
+)
+
+// env-file is an exec.d executable that loads the files listed in
+// NPM_START_ENV_FILES into the environment of the launch process. Exec.d
+// executables report the variables to set as TOML on file descriptor 3.
+func main() {
+	env, err := envfile.Load(filepath.SplitList(os.Getenv("NPM_START_ENV_FILES")), os.LookupEnv)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load env files: %s\n", err)
+		os.Exit(1)
+	}
+
+	err = toml.NewEncoder(os.NewFile(3, "/dev/fd/3")).Encode(env)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to write env: %s\n", err)
+		os.Exit(1)
+	}
+}
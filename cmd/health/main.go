@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+)
+
+// DefaultPort is probed when neither --port nor PORT is set.
+const DefaultPort = "8080"
+
+// health probes the app listening on 127.0.0.1 and exits with 0 when it is
+// healthy and 1 otherwise, so that container runtimes without curl can run
+// it as a health check. It requests the given path over HTTP, or only
+// connects to the port with --tcp.
+func main() {
+	var (
+		path    = flag.String("path", "/", "path to request over HTTP")
+		port    = flag.String("port", "", "port to probe, defaults to $PORT or "+DefaultPort)
+		tcp     = flag.Bool("tcp", false, "only check that the port accepts connections")
+		timeout = flag.Duration("timeout", 3*time.Second, "time to wait for the app")
+	)
+	flag.Parse()
+
+	if *port == "" {
+		*port = os.Getenv("PORT")
+	}
+	if *port == "" {
+		*port = DefaultPort
+	}
+
+	endpoint := &url.URL{Scheme: "http", Host: "127.0.0.1:" + *port, Path: *path}
+	if *tcp {
+		endpoint = &url.URL{Scheme: "tcp", Host: "127.0.0.1:" + *port}
+	}
+
+	err := launcher.Probe(context.Background(), endpoint, *timeout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unhealthy: %s: %s\n", endpoint, err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("healthy: %s\n", endpoint)
+}
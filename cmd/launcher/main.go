@@ -0,0 +1,26 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+)
+
+// launcher supervises the start processes described by the config file
+// given as its first argument. Any further arguments are passed on to the
+// processes.
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: launcher <config> [args...]")
+		os.Exit(1)
+	}
+
+	config, err := launcher.Load(os.Args[1])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	os.Exit(launcher.NewLauncher(config, os.Stdout, os.Stderr).Run(os.Args[2:]))
+}
@@ -0,0 +1,102 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+var concurrentName = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
+// concurrentScript is an npm script that runs alongside the others listed in
+// BP_NPM_START_CONCURRENT.
+type concurrentScript struct {
+	Name   string
+	Script string
+}
+
+// concurrentScripts parses BP_NPM_START_CONCURRENT, a comma-separated list of
+// name:script pairs such as "web:start,worker:worker". A bare script name is
+// also used as its name.
+func concurrentScripts() ([]concurrentScript, error) {
+	value := os.Getenv("BP_NPM_START_CONCURRENT")
+
+	var scripts []concurrentScript
+	for _, entry := range splitList(value) {
+		name, script, ok := strings.Cut(entry, ":")
+		if !ok {
+			script = name
+		}
+		name, script = strings.TrimSpace(name), strings.TrimSpace(script)
+
+		if !concurrentName.MatchString(name) || script == "" {
+			return nil, fmt.Errorf("failed to parse BP_NPM_START_CONCURRENT value %s: %q must be a name:script pair with a name made of letters, digits, '.', '_' or '-'", value, entry)
+		}
+
+		for _, other := range scripts {
+			if other.Name == name {
+				return nil, fmt.Errorf("failed to parse BP_NPM_START_CONCURRENT value %s: name %q is used more than once", value, name)
+			}
+		}
+
+		scripts = append(scripts, concurrentScript{Name: name, Script: script})
+	}
+
+	return scripts, nil
+}
+
+// shouldKillOthers reports whether BP_NPM_START_CONCURRENT_KILL_OTHERS, which
+// defaults to true, is enabled.
+func shouldKillOthers() (bool, error) {
+	value, ok := os.LookupEnv("BP_NPM_START_CONCURRENT_KILL_OTHERS")
+	if !ok || value == "" {
+		return true, nil
+	}
+
+	killOthers, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse BP_NPM_START_CONCURRENT_KILL_OTHERS value %s: %w", value, err)
+	}
+
+	return killOthers, nil
+}
+
+// concurrentProcess generates a StartupScript for each of the given npm
+// scripts of target, including their pre and post scripts, in layerPath and
+// returns a process that runs them side by side under the launcher with the
+// given config.
+func concurrentProcess(logger scribe.Emitter, target startTarget, scripts []concurrentScript, config launcher.Config, validation string, development bool, workingDir, layerPath string) (packit.Process, error) {
+	for _, script := range scripts {
+		command, ok := target.Manifest.Scripts[script.Script]
+		if !ok {
+			return packit.Process{}, fmt.Errorf("failed to parse BP_NPM_START_CONCURRENT: no script %q in %s", script.Script, filepath.Join(target.Path, "package.json"))
+		}
+
+		child := target
+		child.Type = script.Name
+		child.Script = filepath.Join(layerPath, fmt.Sprintf("%s-%s.sh", target.Type, script.Name))
+		child.Package.Scripts.Start = command
+		child.Package.Scripts.PreStart = target.Manifest.Scripts["pre"+script.Script]
+		child.Package.Scripts.PostStart = target.Manifest.Scripts["post"+script.Script]
+
+		process, err := startProcess(logger, child, false, validation, development, workingDir)
+		if err != nil {
+			return packit.Process{}, err
+		}
+
+		config.Children = append(config.Children, launcher.Child{
+			Name:    script.Name,
+			Script:  script.Script,
+			Command: append([]string{process.Command}, process.Args...),
+		})
+	}
+
+	return writeLauncherProcess(target.Type, target.Default, config, layerPath)
+}
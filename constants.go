@@ -15,3 +15,78 @@ wait $CPID
 trap - TERM INT
 wait $CPID
 `
+
+// RelayStartupScript is the StartupScript of apps that handle a signal, such
+// as the heap snapshot signal of node, without exiting. The script relays the
+// signal to every process in its process group and keeps waiting for the
+// scripts, which ignore the signal, unlike node, which resets the signals it
+// inherits.
+const RelayStartupScript = `trap 'kill -TERM $CPID' TERM
+trap 'kill -INT $CPID' INT
+relay() { trap '' %[1]s; kill -%[1]s 0; trap relay %[1]s; }
+trap relay %[1]s
+( trap '' %[1]s; %[2]s ) &
+CPID="$!"
+wait $CPID
+status=$?
+while kill -0 $CPID 2>/dev/null; do
+  wait $CPID
+  status=$?
+done
+exit $status
+`
+
+// SupervisedStartupScript is the StartupScript of apps run under the
+// launcher, which relays every signal other than SIGTERM and SIGINT to the
+// whole process group of the script. The script and its subshell only catch
+// them, so that they reach the app without stopping the script, and the
+// script keeps waiting for the scripts until they exit.
+const SupervisedStartupScript = `trap 'kill -TERM $CPID' TERM
+trap 'kill -INT $CPID' INT
+trap : HUP QUIT USR1 USR2
+( trap : HUP QUIT USR1 USR2; %s ) &
+CPID="$!"
+wait $CPID
+status=$?
+while kill -0 $CPID 2>/dev/null; do
+  wait $CPID
+  status=$?
+done
+exit $status
+`
+
+// ReinstallScript runs in place of the reloadable process when dependencies
+// are reinstalled on manifest changes. It installs the dependencies when
+// package.json or package-lock.json changed since the previous run and then
+// starts the reloadable process. A failed install is reported and leaves the
+// app stopped until the manifests change again, rather than restarting it
+// against stale node_modules.
+const ReinstallScript = `cd %s
+state="${TMPDIR:-/tmp}/npm-start-manifests"
+mkdir -p "$state"
+
+changed() {
+  [ -f "$state/$1" ] && [ "$(cksum < "$1" 2>/dev/null)" != "$(cat "$state/$1")" ]
+}
+
+install=""
+if changed package-lock.json; then
+  install="npm ci"
+elif changed package.json; then
+  install="npm install --no-save"
+fi
+
+if [ -n "$install" ]; then
+  echo "Dependency manifests changed, running '$install'"
+  if ! $install; then
+    echo "ERROR: '$install' failed; the app was not restarted. Fix package.json or package-lock.json to retry." >&2
+    exit 1
+  fi
+fi
+
+for manifest in package.json package-lock.json; do
+  cksum < "$manifest" > "$state/$manifest" 2>/dev/null
+done
+
+exec %s
+`
@@ -3,13 +3,15 @@ package npmstart
 import (
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/paketo-buildpacks/libnodejs"
-	"github.com/paketo-buildpacks/libreload-packit"
+	"github.com/paketo-buildpacks/npm-start/reload"
 	"github.com/paketo-buildpacks/packit/v2"
 )
 
-type Reloader libreload.Reloader
+type Reloader reload.Reloader
 
 //go:generate faux --interface Reloader --output fakes/reloader.go
 
@@ -17,21 +19,27 @@ const NoStartScriptError = "no start script in package.json"
 
 func Detect(reloader Reloader) packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
-		projectPath, err := libnodejs.FindProjectPath(context.WorkingDir)
+		development := isDevelopment()
+
+		listedPaths, err := listedProjectPaths(context.WorkingDir)
 		if err != nil {
 			return packit.DetectResult{}, err
 		}
 
-		pkg, err := libnodejs.ParsePackageJSON(projectPath)
-		if err != nil {
-			if os.IsNotExist(err) {
-				return packit.DetectResult{}, packit.Fail.WithMessage("no 'package.json' found in project path %s", projectPath)
+		if len(listedPaths) > 0 {
+			targets, _, err := projectPathTargets(context.WorkingDir, listedPaths, "")
+			if err != nil {
+				return packit.DetectResult{}, err
 			}
-			return packit.DetectResult{}, fmt.Errorf("failed to open package.json: %w", err)
-		}
 
-		if !pkg.HasStartScript() {
-			return packit.DetectResult{}, packit.Fail.WithMessage(NoStartScriptError)
+			if len(targets) == 0 {
+				return packit.DetectResult{}, packit.Fail.WithMessage("%s: none of the project paths %s has a start script", NoStartScriptError, strings.Join(listedPaths, ", "))
+			}
+		} else {
+			err = detectStartScript(context.WorkingDir, development)
+			if err != nil {
+				return packit.DetectResult{}, err
+			}
 		}
 
 		shouldLaunchWithTini, err := libnodejs.ShouldLaunchWithTini()
@@ -64,22 +72,32 @@ func Detect(reloader Reloader) packit.DetectFunc {
 			})
 		}
 
+		nodeModulesMetadata := map[string]interface{}{
+			"launch": true,
+		}
+
+		// Requiring node_modules at build as well installs the dev
+		// dependencies that the development profile needs.
+		if development {
+			nodeModulesMetadata["build"] = true
+		}
+
 		requirements = append(requirements, packit.BuildPlanRequirement{
-			Name: NodeModules,
-			Metadata: map[string]interface{}{
-				"launch": true,
-			},
+			Name:     NodeModules,
+			Metadata: nodeModulesMetadata,
 		})
 
 		if shouldReload, err := reloader.ShouldEnableLiveReload(); err != nil {
 			return packit.DetectResult{}, err
-		} else if shouldReload {
-			requirements = append(requirements, packit.BuildPlanRequirement{
-				Name: "watchexec",
-				Metadata: map[string]interface{}{
-					"launch": true,
-				},
-			})
+		} else if shouldReload || development {
+			for _, name := range reloader.LaunchRequirements() {
+				requirements = append(requirements, packit.BuildPlanRequirement{
+					Name: name,
+					Metadata: map[string]interface{}{
+						"launch": true,
+					},
+				})
+			}
 		}
 
 		return packit.DetectResult{
@@ -89,3 +107,65 @@ func Detect(reloader Reloader) packit.DetectFunc {
 		}, nil
 	}
 }
+
+// detectStartScript checks that the app in the project path, or the
+// workspace packages it starts, have a start script.
+func detectStartScript(workingDir string, development bool) error {
+	projectPath, err := libnodejs.FindProjectPath(workingDir)
+	if err != nil {
+		return err
+	}
+
+	pkg, err := libnodejs.ParsePackageJSON(projectPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return packit.Fail.WithMessage("no 'package.json' found in project path %s", projectPath)
+		}
+		if _, manifestErr := parsePackageManifest(projectPath); manifestErr != nil {
+			return fmt.Errorf("failed to parse package.json in project path %s: %w", projectPath, manifestErr)
+		}
+		return fmt.Errorf("failed to open package.json: %w", err)
+	}
+
+	manifest, err := parsePackageManifest(projectPath)
+	if err != nil {
+		return fmt.Errorf("failed to parse package.json in project path %s: %w", projectPath, err)
+	}
+
+	workspaceProcesses, err := shouldStartWorkspaceProcesses()
+	if err != nil {
+		return err
+	}
+
+	target, err := startWorkspace(projectPath, manifest)
+	if err != nil {
+		return err
+	}
+
+	if target.Path != "" {
+		projectPath = target.Path
+		manifest = target.Manifest
+
+		pkg, err = libnodejs.ParsePackageJSON(projectPath)
+		if err != nil {
+			return fmt.Errorf("failed to open package.json: %w", err)
+		}
+	}
+
+	if workspaceProcesses {
+		targets, err := workspaceTargets(projectPath, manifest, "")
+		if err != nil {
+			return err
+		}
+
+		if len(targets) == 0 {
+			return packit.Fail.WithMessage("%s: no workspace declared in %s has a start script", NoStartScriptError, filepath.Join(projectPath, "package.json"))
+		}
+	} else if !pkg.HasStartScript() {
+		if _, ok := developmentStartScript(manifest); !development || !ok {
+			return packit.Fail.WithMessage("%s", describeMissingStartScript(projectPath, manifest))
+		}
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package npmstart_test
 
 import (
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
@@ -76,9 +77,10 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 		context("when live reload is enabled", func() {
 			it.Before(func() {
 				reloader.ShouldEnableLiveReloadCall.Returns.Bool = true
+				reloader.LaunchRequirementsCall.Returns.StringSlice = []string{"watchexec"}
 			})
 
-			it("requires watchexec at launch", func() {
+			it("requires node_modules with dev dependencies and the reloader's launch requirements", func() {
 				result, err := detect(packit.DetectContext{
 					WorkingDir: workingDir,
 				})
@@ -114,6 +116,67 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when live reload is enabled with a reloader that has no launch requirements", func() {
+			it.Before(func() {
+				reloader.ShouldEnableLiveReloadCall.Returns.Bool = true
+			})
+
+			it("does not require watchexec", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(HaveLen(3))
+				Expect(result.Plan.Requires).NotTo(ContainElement(HaveField("Name", "watchexec")))
+			})
+		})
+
+		context("when BP_ENVIRONMENT is development", func() {
+			it.Before(func() {
+				t.Setenv("BP_ENVIRONMENT", "development")
+				reloader.LaunchRequirementsCall.Returns.StringSlice = []string{"watchexec"}
+			})
+
+			it("requires node_modules with dev dependencies and the reloader's launch requirements", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(ContainElement(packit.BuildPlanRequirement{
+					Name: "node_modules",
+					Metadata: map[string]interface{}{
+						"launch": true,
+						"build":  true,
+					},
+				}))
+				Expect(result.Plan.Requires).To(ContainElement(packit.BuildPlanRequirement{
+					Name: "watchexec",
+					Metadata: map[string]interface{}{
+						"launch": true,
+					},
+				}))
+			})
+		})
+
+		context("when BP_ENVIRONMENT is neither production nor development", func() {
+			it.Before(func() {
+				t.Setenv("BP_ENVIRONMENT", "staging")
+			})
+
+			it("treats it as production", func() {
+				result, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Expect(result.Plan.Requires).To(ContainElement(packit.BuildPlanRequirement{
+					Name: "node_modules",
+					Metadata: map[string]interface{}{
+						"launch": true,
+					},
+				}))
+			})
+		})
+
 		context("when BP_LAUNCH_WITH_TINI is true", func() {
 			it.Before(func() {
 				t.Setenv("BP_LAUNCH_WITH_TINI", "true")
@@ -169,6 +232,198 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 				WorkingDir: workingDir,
 			})
 			Expect(err).To(MatchError(ContainSubstring(npmstart.NoStartScriptError)))
+			Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf(`looked for script "start" in %s`, filepath.Join(workingDir, "custom", "package.json")))))
+			Expect(err).To(MatchError(ContainSubstring("available scripts: poststart, prestart")))
+			Expect(err).NotTo(MatchError(ContainSubstring("did you mean")))
+		})
+
+		context("when BP_NPM_START_SCRIPT names a script that is close to an existing one", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_SCRIPT", "serv")
+				Expect(os.WriteFile(filepath.Join(workingDir, "custom", "package.json"), []byte(`{
+					"scripts": {
+						"build": "tsc",
+						"serve": "node dist/server.js"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("reports the configured script and suggests the closest match", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(`looked for script "serv"`)))
+				Expect(err).To(MatchError(ContainSubstring("available scripts: build, serve")))
+				Expect(err).To(MatchError(ContainSubstring(`did you mean "serve"?`)))
+			})
+		})
+
+		context("when BP_ENVIRONMENT is development and there is a dev script", func() {
+			it.Before(func() {
+				t.Setenv("BP_ENVIRONMENT", "development")
+				Expect(os.WriteFile(filepath.Join(workingDir, "custom", "package.json"), []byte(`{
+					"scripts": {
+						"dev": "node --inspect server.js"
+					}
+				}`), 0600)).To(Succeed())
+			})
+
+			it("detects", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			context("when BP_NPM_START_SCRIPT is set", func() {
+				it.Before(func() {
+					t.Setenv("BP_NPM_START_SCRIPT", "serve")
+				})
+
+				it("fails detection", func() {
+					_, err := detect(packit.DetectContext{
+						WorkingDir: workingDir,
+					})
+					Expect(err).To(MatchError(ContainSubstring(`looked for script "serve"`)))
+				})
+			})
+		})
+
+		context("when package.json defines no scripts", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "custom", "package.json"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("says so", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("package.json defines no scripts")))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_WORKSPACE is set", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_WORKSPACE", "api")
+			Expect(os.WriteFile(filepath.Join(workingDir, "custom", "package.json"), []byte(`{
+				"workspaces": ["packages/*"]
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "custom", "packages", "api"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "custom", "packages", "api", "package.json"), []byte(`{
+				"name": "api",
+				"scripts": {
+					"start": "node server.js"
+				}
+			}`), 0600)).To(Succeed())
+		})
+
+		it("detects using the workspace start script", func() {
+			_, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		context("when the workspace has no start script", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "custom", "packages", "api", "package.json"), []byte(`{
+					"name": "api"
+				}`), 0600)).To(Succeed())
+			})
+
+			it("fails detection", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(filepath.Join(workingDir, "custom", "packages", "api", "package.json"))))
+			})
+		})
+
+		context("when no workspace matches", func() {
+			it.Before(func() {
+				t.Setenv("BP_NPM_START_WORKSPACE", "admin")
+			})
+
+			it("returns an error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("failed to resolve BP_NPM_START_WORKSPACE value admin")))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_WORKSPACE_PROCESSES is true", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_WORKSPACE_PROCESSES", "true")
+			Expect(os.WriteFile(filepath.Join(workingDir, "custom", "package.json"), []byte(`{
+				"workspaces": ["packages/*"]
+			}`), 0600)).To(Succeed())
+
+			Expect(os.MkdirAll(filepath.Join(workingDir, "custom", "packages", "api"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "custom", "packages", "api", "package.json"), []byte(`{
+				"name": "api",
+				"scripts": {
+					"start": "node server.js"
+				}
+			}`), 0600)).To(Succeed())
+		})
+
+		it("detects when a workspace has a start script", func() {
+			_, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		context("when no workspace has a start script", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "custom", "packages", "api", "package.json"), []byte(`{
+					"name": "api"
+				}`), 0600)).To(Succeed())
+			})
+
+			it("fails detection", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("no workspace declared in")))
+			})
+		})
+	})
+
+	context("when BP_NPM_START_PROJECT_PATHS lists several project paths", func() {
+		it.Before(func() {
+			t.Setenv("BP_NPM_START_PROJECT_PATHS", "custom,admin")
+			Expect(os.Mkdir(filepath.Join(workingDir, "admin"), os.ModePerm)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(workingDir, "admin", "package.json"), []byte(`{
+				"scripts": {
+					"start": "node server.js"
+				}
+			}`), 0600)).To(Succeed())
+		})
+
+		it("detects when one of them has a start script", func() {
+			result, err := detect(packit.DetectContext{
+				WorkingDir: workingDir,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Plan.Requires).To(HaveLen(3))
+		})
+
+		context("when none of them has a start script", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "admin", "package.json"), []byte(`{}`), 0600)).To(Succeed())
+			})
+
+			it("fails detection", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring("none of the project paths")))
+			})
 		})
 	})
 
@@ -199,6 +454,25 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			})
 		})
 
+		context("when the package.json is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "custom", "package.json"), []byte(`{
+	"scripts": {
+		"start": "node server.js",
+	}
+}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error with the location of the syntax error", func() {
+				_, err := detect(packit.DetectContext{
+					WorkingDir: workingDir,
+				})
+				Expect(err).To(MatchError(ContainSubstring(fmt.Sprintf("failed to parse package.json in project path %s", filepath.Join(workingDir, "custom")))))
+				Expect(err).To(MatchError(ContainSubstring("invalid character '}'")))
+				Expect(err).To(MatchError(ContainSubstring("(line 4, column 2)")))
+			})
+		})
+
 		context("when the project path cannot be found", func() {
 			it.Before(func() {
 				t.Setenv("BP_NODE_PROJECT_PATH", "does-not-exist")
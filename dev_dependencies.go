@@ -0,0 +1,134 @@
+package npmstart
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// devDependencyHints are targeted fixes for the development tools that most
+// commonly end up in start scripts.
+var devDependencyHints = map[string]string{
+	"nodemon":      "run the app with node directly and set BP_LIVE_RELOAD_ENABLED=true to restart it on changes",
+	"ts-node":      "compile the app with tsc during the build and run the output with node",
+	"tsx":          "compile the app with tsc during the build and run the output with node",
+	"concurrently": "move \"concurrently\" to dependencies",
+}
+
+// checkDevDependencies returns a description of every binary or preloaded
+// module used by the given scripts that is only provided by a package listed
+// in devDependencies, and will therefore be missing at launch once dev
+// dependencies are pruned.
+func checkDevDependencies(scripts []string, projectPath string, manifest packageManifest) []string {
+	if len(manifest.DevDependencies) == 0 {
+		return nil
+	}
+
+	bins := map[string]string{}
+	for name := range manifest.DevDependencies {
+		if _, ok := manifest.Dependencies[name]; ok {
+			continue
+		}
+
+		for _, bin := range packageBins(projectPath, name) {
+			bins[bin] = name
+		}
+	}
+
+	var problems []string
+	for _, script := range scripts {
+		for _, words := range splitShellCommands(script) {
+			words = trimCommandPrefix(words)
+			if len(words) > 0 && words[0] == "npx" {
+				words = trimCommandPrefix(words[1:])
+			}
+
+			if len(words) == 0 {
+				continue
+			}
+
+			if pkg, ok := bins[words[0]]; ok {
+				problems = append(problems, devDependencyProblem("binary", words[0], pkg))
+			}
+
+			if words[0] != "node" {
+				continue
+			}
+
+			for i := 1; i < len(words)-1; i++ {
+				if !slices.Contains([]string{"-r", "--require", "--import", "--loader", "--experimental-loader"}, words[i]) {
+					continue
+				}
+
+				pkg := modulePackage(words[i+1])
+				if _, ok := manifest.DevDependencies[pkg]; ok {
+					if _, ok := manifest.Dependencies[pkg]; !ok {
+						problems = append(problems, devDependencyProblem("module", words[i+1], pkg))
+					}
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+func devDependencyProblem(kind, name, pkg string) string {
+	hint, ok := devDependencyHints[pkg]
+	if !ok {
+		hint = fmt.Sprintf("move %q to dependencies", pkg)
+	}
+
+	return fmt.Sprintf("start command uses %s %q from devDependency %q, which is not available at launch when dev dependencies are pruned; %s", kind, name, pkg, hint)
+}
+
+// packageBins returns the names of the binaries provided by the named
+// package, read from its installed package.json. When the package is not
+// installed, the unscoped package name is assumed to be its binary.
+func packageBins(projectPath, name string) []string {
+	fallback := []string{path.Base(name)}
+
+	content, err := os.ReadFile(filepath.Join(projectPath, "node_modules", name, "package.json"))
+	if err != nil {
+		return fallback
+	}
+
+	var pkg struct {
+		Bin json.RawMessage `json:"bin"`
+	}
+	if err := json.Unmarshal(content, &pkg); err != nil || len(pkg.Bin) == 0 {
+		return fallback
+	}
+
+	var single string
+	if err := json.Unmarshal(pkg.Bin, &single); err == nil {
+		return fallback
+	}
+
+	var named map[string]string
+	if err := json.Unmarshal(pkg.Bin, &named); err != nil {
+		return fallback
+	}
+
+	var bins []string
+	for bin := range named {
+		bins = append(bins, bin)
+	}
+
+	return bins
+}
+
+// modulePackage returns the package name of a module specifier such as
+// "ts-node/register" or "@scope/pkg/register".
+func modulePackage(specifier string) string {
+	parts := strings.Split(specifier, "/")
+	if strings.HasPrefix(specifier, "@") && len(parts) > 1 {
+		return parts[0] + "/" + parts[1]
+	}
+
+	return parts[0]
+}
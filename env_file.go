@@ -0,0 +1,20 @@
+package npmstart
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// envFilePaths returns the env files configured with BP_NPM_START_ENV_FILE
+// as a comma-separated list, resolved relative to projectPath.
+func envFilePaths(projectPath string) []string {
+	var paths []string
+	for _, path := range splitList(os.Getenv("BP_NPM_START_ENV_FILE")) {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(projectPath, path)
+		}
+		paths = append(paths, path)
+	}
+
+	return paths
+}
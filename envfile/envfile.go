@@ -0,0 +1,128 @@
+// Package envfile loads dotenv-style files into the environment of the launch
+// process without overriding values that are already set.
+package envfile
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var validKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.]*$`)
+
+// Parse reads KEY=VALUE pairs from r. Blank lines and lines starting with # are
+// ignored, an optional "export " prefix is allowed, and values may be wrapped
+// in single quotes (taken literally) or double quotes (supporting \n, \t, \"
+// and \\ escapes). Unquoted values end at an unescaped " #" comment.
+func Parse(r io.Reader) (map[string]string, error) {
+	env := map[string]string{}
+
+	scanner := bufio.NewScanner(r)
+	for number := 1; scanner.Scan(); number++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || !validKey.MatchString(key) {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE, got %q", number, scanner.Text())
+		}
+
+		value, err := parseValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", number, err)
+		}
+
+		env[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return env, nil
+}
+
+func parseValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '\'':
+		end := strings.IndexByte(value[1:], '\'')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated single-quoted value %s", value)
+		}
+		return value[1 : end+1], nil
+
+	case '"':
+		var builder strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; c {
+			case '"':
+				return builder.String(), nil
+			case '\\':
+				if i+1 == len(value) {
+					return "", fmt.Errorf("unterminated double-quoted value %s", value)
+				}
+				i++
+				switch value[i] {
+				case 'n':
+					builder.WriteByte('\n')
+				case 't':
+					builder.WriteByte('\t')
+				default:
+					builder.WriteByte(value[i])
+				}
+			default:
+				builder.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated double-quoted value %s", value)
+	}
+
+	if comment := strings.Index(value, " #"); comment >= 0 {
+		value = strings.TrimSpace(value[:comment])
+	}
+
+	return value, nil
+}
+
+// Load parses each of the given files in order, with later files taking
+// precedence over earlier ones, and returns the variables that are not
+// already set according to lookup. Files that do not exist are skipped.
+func Load(paths []string, lookup func(string) (string, bool)) (map[string]string, error) {
+	env := map[string]string{}
+	for _, path := range paths {
+		file, err := os.Open(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+
+		values, err := Parse(file)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse env file %s: %w", path, err)
+		}
+
+		for key, value := range values {
+			if _, ok := lookup(key); ok {
+				continue
+			}
+			env[key] = value
+		}
+	}
+
+	return env, nil
+}
@@ -0,0 +1,93 @@
+package envfile_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paketo-buildpacks/npm-start/envfile"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testEnvFile(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("Parse", func() {
+		it("parses keys and values", func() {
+			env, err := envfile.Parse(strings.NewReader(`
+# a comment
+PLAIN=value
+export EXPORTED=exported
+SPACED = spaced value # trailing comment
+EMPTY=
+SINGLE='literal \n $HOME'
+DOUBLE="line one\nline \"two\""
+HASH=value#not-a-comment
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env).To(Equal(map[string]string{
+				"PLAIN":    "value",
+				"EXPORTED": "exported",
+				"SPACED":   "spaced value",
+				"EMPTY":    "",
+				"SINGLE":   `literal \n $HOME`,
+				"DOUBLE":   "line one\nline \"two\"",
+				"HASH":     "value#not-a-comment",
+			}))
+		})
+
+		context("failure cases", func() {
+			it("returns an error for a line without a key", func() {
+				_, err := envfile.Parse(strings.NewReader("VALID=1\nnot a pair\n"))
+				Expect(err).To(MatchError(`line 2: expected KEY=VALUE, got "not a pair"`))
+			})
+
+			it("returns an error for an unterminated quote", func() {
+				_, err := envfile.Parse(strings.NewReader(`KEY="value`))
+				Expect(err).To(MatchError(`line 1: unterminated double-quoted value "value`))
+			})
+		})
+	})
+
+	context("Load", func() {
+		var dir string
+
+		it.Before(func() {
+			dir = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(dir, ".env"), []byte("FIRST=from-env\nSHARED=from-env\nPRESET=from-env\n"), 0600)).To(Succeed())
+			Expect(os.WriteFile(filepath.Join(dir, ".env.local"), []byte("SHARED=from-local\n"), 0600)).To(Succeed())
+		})
+
+		it("merges files in order without overriding existing values", func() {
+			env, err := envfile.Load([]string{
+				filepath.Join(dir, ".env"),
+				filepath.Join(dir, "does-not-exist"),
+				filepath.Join(dir, ".env.local"),
+			}, func(key string) (string, bool) {
+				if key == "PRESET" {
+					return "already-set", true
+				}
+				return "", false
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(env).To(Equal(map[string]string{
+				"FIRST":  "from-env",
+				"SHARED": "from-local",
+			}))
+		})
+
+		context("when a file is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(dir, ".env"), []byte("%%%\n"), 0600)).To(Succeed())
+			})
+
+			it("returns an error naming the file", func() {
+				_, err := envfile.Load([]string{filepath.Join(dir, ".env")}, os.LookupEnv)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse env file " + filepath.Join(dir, ".env"))))
+			})
+		})
+	})
+}
@@ -0,0 +1,14 @@
+package envfile_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitEnvFile(t *testing.T) {
+	suite := spec.New("envfile", spec.Report(report.Terminal{}), spec.Sequential())
+	suite("EnvFile", testEnvFile)
+	suite.Run(t)
+}
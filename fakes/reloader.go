@@ -3,11 +3,19 @@ package fakes
 import (
 	"sync"
 
-	"github.com/paketo-buildpacks/libreload-packit"
+	"github.com/paketo-buildpacks/npm-start/reload"
 	"github.com/paketo-buildpacks/packit/v2"
 )
 
 type Reloader struct {
+	LaunchRequirementsCall struct {
+		mutex     sync.Mutex
+		CallCount int
+		Returns   struct {
+			StringSlice []string
+		}
+		Stub func() []string
+	}
 	ShouldEnableLiveReloadCall struct {
 		mutex     sync.Mutex
 		CallCount int
@@ -22,16 +30,25 @@ type Reloader struct {
 		CallCount int
 		Receives  struct {
 			OriginalProcess packit.Process
-			Spec            libreload.ReloadableProcessSpec
+			Spec            reload.ReloadableProcessSpec
 		}
 		Returns struct {
 			NonReloadable packit.Process
 			Reloadable    packit.Process
 		}
-		Stub func(packit.Process, libreload.ReloadableProcessSpec) (packit.Process, packit.Process)
+		Stub func(packit.Process, reload.ReloadableProcessSpec) (packit.Process, packit.Process)
 	}
 }
 
+func (f *Reloader) LaunchRequirements() []string {
+	f.LaunchRequirementsCall.mutex.Lock()
+	defer f.LaunchRequirementsCall.mutex.Unlock()
+	f.LaunchRequirementsCall.CallCount++
+	if f.LaunchRequirementsCall.Stub != nil {
+		return f.LaunchRequirementsCall.Stub()
+	}
+	return f.LaunchRequirementsCall.Returns.StringSlice
+}
 func (f *Reloader) ShouldEnableLiveReload() (bool, error) {
 	f.ShouldEnableLiveReloadCall.mutex.Lock()
 	defer f.ShouldEnableLiveReloadCall.mutex.Unlock()
@@ -41,7 +58,7 @@ func (f *Reloader) ShouldEnableLiveReload() (bool, error) {
 	}
 	return f.ShouldEnableLiveReloadCall.Returns.Bool, f.ShouldEnableLiveReloadCall.Returns.Error
 }
-func (f *Reloader) TransformReloadableProcesses(param1 packit.Process, param2 libreload.ReloadableProcessSpec) (packit.Process, packit.Process) {
+func (f *Reloader) TransformReloadableProcesses(param1 packit.Process, param2 reload.ReloadableProcessSpec) (packit.Process, packit.Process) {
 	f.TransformReloadableProcessesCall.mutex.Lock()
 	defer f.TransformReloadableProcessesCall.mutex.Unlock()
 	f.TransformReloadableProcessesCall.CallCount++
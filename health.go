@@ -0,0 +1,74 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HealthBinary is the path, relative to the npm-start layer, that the health
+// check is copied to.
+var HealthBinary = filepath.Join("bin", "health")
+
+const (
+	HealthCheckHTTP = "http"
+	HealthCheckTCP  = "tcp"
+
+	DefaultHealthCheckTimeout = 3 * time.Second
+)
+
+// healthCheck parses BP_NPM_START_HEALTH_CHECK and the path, port and timeout
+// that go with it, and returns the arguments of the health binary along with
+// a description of what it probes. It returns nil arguments when no health
+// process is requested.
+func healthCheck() ([]string, string, error) {
+	mode := os.Getenv("BP_NPM_START_HEALTH_CHECK")
+	switch mode {
+	case "":
+		return nil, "", nil
+	case HealthCheckHTTP, HealthCheckTCP:
+	default:
+		return nil, "", fmt.Errorf("failed to parse BP_NPM_START_HEALTH_CHECK value %s: must be one of %s or %s", mode, HealthCheckHTTP, HealthCheckTCP)
+	}
+
+	var args []string
+
+	port := "$PORT"
+	if value := os.Getenv("BP_NPM_START_HEALTH_CHECK_PORT"); value != "" {
+		if number, err := strconv.Atoi(value); err != nil || number < 1 || number > 65535 {
+			return nil, "", fmt.Errorf("failed to parse BP_NPM_START_HEALTH_CHECK_PORT value %s: must be a port number", value)
+		}
+		port = value
+		args = append(args, "--port", value)
+	}
+
+	target := fmt.Sprintf("tcp://127.0.0.1:%s", port)
+	if mode == HealthCheckTCP {
+		args = append(args, "--tcp")
+	} else {
+		path := "/"
+		if value := os.Getenv("BP_NPM_START_HEALTH_CHECK_PATH"); value != "" {
+			if !strings.HasPrefix(value, "/") {
+				return nil, "", fmt.Errorf("failed to parse BP_NPM_START_HEALTH_CHECK_PATH value %s: must start with /", value)
+			}
+			path = value
+		}
+		target = fmt.Sprintf("http://127.0.0.1:%s%s", port, path)
+		args = append(args, "--path", path)
+	}
+
+	timeout := DefaultHealthCheckTimeout
+	if value := os.Getenv("BP_NPM_START_HEALTH_CHECK_TIMEOUT"); value != "" {
+		var err error
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, "", fmt.Errorf("failed to parse BP_NPM_START_HEALTH_CHECK_TIMEOUT value %s: must be a positive duration such as 3s", value)
+		}
+	}
+	args = append(args, "--timeout", timeout.String())
+
+	return args, fmt.Sprintf("%s, timeout %s", target, timeout), nil
+}
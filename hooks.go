@@ -0,0 +1,114 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+)
+
+const (
+	DefaultHookRetries    = 3
+	DefaultHookRetryDelay = time.Second
+)
+
+// hookPolicy parses the timeout and failure policy of the named hook, either
+// prestart or poststart, from BP_NPM_START_<HOOK>_TIMEOUT,
+// BP_NPM_START_<HOOK>_ON_FAILURE and BP_NPM_START_<HOOK>_RETRIES. It returns
+// nil when none of them is set, in which case the hook runs inline in the
+// start script.
+func hookPolicy(name string) (*launcher.Hook, error) {
+	prefix := fmt.Sprintf("BP_NPM_START_%s_", strings.ToUpper(name))
+	timeout, onFailure, retries := os.Getenv(prefix+"TIMEOUT"), os.Getenv(prefix+"ON_FAILURE"), os.Getenv(prefix+"RETRIES")
+	if timeout == "" && onFailure == "" && retries == "" {
+		return nil, nil
+	}
+
+	hook := &launcher.Hook{
+		OnFailure: launcher.HookFail,
+	}
+
+	if timeout != "" {
+		var err error
+		hook.Timeout, err = time.ParseDuration(timeout)
+		if err != nil || hook.Timeout <= 0 {
+			return nil, fmt.Errorf("failed to parse %sTIMEOUT value %s: must be a positive duration such as 30s", prefix, timeout)
+		}
+	}
+
+	switch onFailure {
+	case "":
+	case launcher.HookFail, launcher.HookContinue, launcher.HookRetry:
+		hook.OnFailure = onFailure
+	default:
+		return nil, fmt.Errorf("failed to parse %sON_FAILURE value %s: must be one of %s, %s or %s", prefix, onFailure, launcher.HookFail, launcher.HookContinue, launcher.HookRetry)
+	}
+
+	if hook.OnFailure == launcher.HookRetry {
+		hook.Retries = DefaultHookRetries
+		hook.Delay = DefaultHookRetryDelay
+	}
+
+	if retries != "" {
+		if hook.OnFailure != launcher.HookRetry {
+			return nil, fmt.Errorf("%sRETRIES requires %sON_FAILURE to be %s", prefix, prefix, launcher.HookRetry)
+		}
+
+		var err error
+		hook.Retries, err = strconv.Atoi(retries)
+		if err != nil || hook.Retries < 1 {
+			return nil, fmt.Errorf("failed to parse %sRETRIES value %s: must be a positive integer", prefix, retries)
+		}
+	}
+
+	return hook, nil
+}
+
+// loggedHook returns the policy of a prestart or poststart script that runs
+// under the launcher only for its runs to be reported as lifecycle events. It
+// has no timeout and fails the process when the script fails, like the script
+// does when it runs inline.
+func loggedHook() *launcher.Hook {
+	return &launcher.Hook{
+		OnFailure: launcher.HookFail,
+	}
+}
+
+// hookDescription describes the timeout and failure policy of a hook.
+func hookDescription(hook *launcher.Hook) string {
+	timeout := "no timeout"
+	if hook.Timeout > 0 {
+		timeout = fmt.Sprintf("timeout %s", hook.Timeout)
+	}
+
+	switch hook.OnFailure {
+	case launcher.HookContinue:
+		return fmt.Sprintf("%s, continues on failure", timeout)
+	case launcher.HookRetry:
+		return fmt.Sprintf("%s, retried up to %d times on failure", timeout, hook.Retries)
+	default:
+		return fmt.Sprintf("%s, fails the process on failure", timeout)
+	}
+}
+
+// launcherHook writes the script of the named hook of target to layerPath
+// and returns a copy of policy that runs it, or nil when there is no policy
+// or no script.
+func launcherHook(target startTarget, name, script string, policy *launcher.Hook, workingDir, layerPath string) (*launcher.Hook, error) {
+	if policy == nil || script == "" {
+		return nil, nil
+	}
+
+	command, err := lifecycleScript(target, name, "", script, "", workingDir, layerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	hook := *policy
+	hook.Command = command
+
+	return &hook, nil
+}
@@ -0,0 +1,231 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Config describes the processes that the launcher supervises. It is
+// written to the launch layer at build time and read by the launcher at
+// launch.
+type Config struct {
+	// Children are run side by side.
+	Children []Child `json:"children"`
+
+	// KillOthers stops the remaining children as soon as one of them exits.
+	KillOthers bool `json:"kill_others,omitempty"`
+
+	// Restart decides whether children that exit are started again.
+	Restart RestartPolicy `json:"restart,omitempty"`
+
+	// Stop runs the npm stop lifecycle when the launcher receives SIGTERM.
+	Stop *StopHook `json:"stop,omitempty"`
+
+	// Reload runs the npm restart lifecycle in place when the launcher
+	// receives its signal.
+	Reload *ReloadHook `json:"reload,omitempty"`
+
+	// WaitFor holds back the children until the endpoints it lists are
+	// ready.
+	WaitFor *WaitFor `json:"wait_for,omitempty"`
+
+	// LogFormat is LogFormatText, the default, or LogFormatJSON to report
+	// lifecycle events as JSON objects.
+	LogFormat string `json:"log_format,omitempty"`
+}
+
+// ReloadHook mirrors npm restart: it runs the restart script when there is
+// one and otherwise stops and starts the children again, running the stop
+// hook first. Pre and Post run before and after restarting the children.
+type ReloadHook struct {
+	// Signal is the name, such as SIGHUP, of the signal that triggers it.
+	Signal string `json:"signal"`
+
+	// Command runs the prerestart, restart and postrestart scripts, leaving
+	// the children running.
+	Command []string `json:"command,omitempty"`
+
+	Pre  []string `json:"pre,omitempty"`
+	Post []string `json:"post,omitempty"`
+}
+
+const (
+	StopBefore   = "before"
+	StopAfter    = "after"
+	StopParallel = "parallel"
+)
+
+// StopHook is a command that runs on shutdown, before the children are
+// signalled, after they have exited, or in parallel. Together with the
+// children, it must finish within Timeout, after which it is killed.
+type StopHook struct {
+	Command []string      `json:"command"`
+	Order   string        `json:"order"`
+	Timeout time.Duration `json:"timeout"`
+}
+
+const (
+	RestartNo        = "no"
+	RestartOnFailure = "on-failure"
+	RestartAlways    = "always"
+
+	// MaxRestartDelay caps the exponential backoff between restarts.
+	MaxRestartDelay = time.Minute
+)
+
+// RestartPolicy restarts children that exit, waiting Backoff before the
+// first restart and twice as long before each following one, up to
+// MaxRestartDelay.
+type RestartPolicy struct {
+	// Policy is one of RestartNo, RestartOnFailure or RestartAlways.
+	Policy string `json:"policy,omitempty"`
+
+	// MaxRetries limits the number of consecutive restarts; zero means
+	// unlimited.
+	MaxRetries int `json:"max_retries,omitempty"`
+
+	Backoff time.Duration `json:"backoff,omitempty"`
+}
+
+func (r RestartPolicy) shouldRestart(code int) bool {
+	switch r.Policy {
+	case RestartAlways:
+		return true
+	case RestartOnFailure:
+		return code != 0
+	default:
+		return false
+	}
+}
+
+// delay returns the time to wait before the given restart, counting from
+// zero.
+func (r RestartPolicy) delay(restart int) time.Duration {
+	delay := r.Backoff
+	for i := 0; i < restart && delay < MaxRestartDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, MaxRestartDelay)
+}
+
+// resetAfter is how long a child has to run for its restarts to be counted
+// afresh.
+func (r RestartPolicy) resetAfter() time.Duration {
+	return max(MaxRestartDelay, r.Backoff)
+}
+
+// Child is a command that the launcher runs.
+type Child struct {
+	// Name prefixes each line of output when there are several children.
+	Name string `json:"name"`
+
+	// Script is the npm script, such as start, that the child runs. It is
+	// reported along with Name in lifecycle events.
+	Script string `json:"script,omitempty"`
+
+	// Command is the executable and its arguments.
+	Command []string `json:"command"`
+
+	// Pre runs before every start of the child, and Post after the child
+	// exits successfully, like the npm prestart and poststart scripts.
+	Pre  *Hook `json:"pre,omitempty"`
+	Post *Hook `json:"post,omitempty"`
+}
+
+const (
+	HookFail     = "fail"
+	HookContinue = "continue"
+	HookRetry    = "retry"
+)
+
+// Hook is a command that runs around a child, such as its prestart script.
+// It is killed when it does not finish within Timeout, unless that is zero.
+// OnFailure decides what happens when it fails or times out: HookFail treats
+// it as the exit of the child, HookContinue carries on as if it had
+// succeeded and HookRetry runs it up to Retries more times, Delay apart,
+// before failing.
+type Hook struct {
+	Command   []string      `json:"command"`
+	Timeout   time.Duration `json:"timeout,omitempty"`
+	OnFailure string        `json:"on_failure,omitempty"`
+	Retries   int           `json:"retries,omitempty"`
+	Delay     time.Duration `json:"delay,omitempty"`
+}
+
+// attempts returns the number of times the hook runs before it fails.
+func (h Hook) attempts() int {
+	if h.OnFailure == HookRetry {
+		return 1 + h.Retries
+	}
+
+	return 1
+}
+
+// Load reads the Config at path.
+func Load(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+
+	var config Config
+	err = json.Unmarshal(content, &config)
+	if err != nil {
+		return Config{}, fmt.Errorf("failed to parse launcher config %s: %w", path, err)
+	}
+
+	if len(config.Children) == 0 {
+		return Config{}, fmt.Errorf("launcher config %s has no children", path)
+	}
+
+	for _, child := range config.Children {
+		if len(child.Command) == 0 {
+			return Config{}, fmt.Errorf("launcher config %s has no command for child %q", path, child.Name)
+		}
+
+		for _, hook := range []*Hook{child.Pre, child.Post} {
+			if hook != nil && len(hook.Command) == 0 {
+				return Config{}, fmt.Errorf("launcher config %s has a hook without a command for child %q", path, child.Name)
+			}
+		}
+	}
+
+	if config.Reload != nil {
+		if _, ok := signalNames[config.Reload.Signal]; !ok {
+			return Config{}, fmt.Errorf("launcher config %s has an unknown reload signal %q", path, config.Reload.Signal)
+		}
+	}
+
+	switch config.LogFormat {
+	case "", LogFormatText, LogFormatJSON:
+	default:
+		return Config{}, fmt.Errorf("launcher config %s has an unknown log format %q", path, config.LogFormat)
+	}
+
+	if config.WaitFor != nil {
+		for _, endpoint := range config.WaitFor.Endpoints {
+			if _, err := ParseEndpoint(endpoint); err != nil {
+				return Config{}, fmt.Errorf("launcher config %s has an invalid endpoint to wait for: %w", path, err)
+			}
+		}
+	}
+
+	if config.Stop != nil && len(config.Stop.Command) == 0 {
+		return Config{}, fmt.Errorf("launcher config %s has no stop command", path)
+	}
+
+	return config, nil
+}
+
+// Write stores config at path.
+func Write(path string, config Config) error {
+	content, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, content, 0644)
+}
@@ -0,0 +1,119 @@
+package launcher_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testConfig(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		path string
+	)
+
+	it.Before(func() {
+		path = filepath.Join(t.TempDir(), "launcher.json")
+	})
+
+	it("round trips a config", func() {
+		config := launcher.Config{
+			Children: []launcher.Child{
+				{Name: "web", Command: []string{"sh", "web.sh"}},
+				{Name: "worker", Command: []string{"sh", "worker.sh"}},
+			},
+			KillOthers: true,
+		}
+
+		Expect(launcher.Write(path, config)).To(Succeed())
+
+		loaded, err := launcher.Load(path)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(loaded).To(Equal(config))
+	})
+
+	context("failure cases", func() {
+		context("when the config is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse launcher config")))
+			})
+		})
+
+		context("when there are no children", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"children": []}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring("has no children")))
+			})
+		})
+
+		context("when the stop hook has no command", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"children": [{"name": "web", "command": ["sh"]}], "stop": {"order": "before"}}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring("has no stop command")))
+			})
+		})
+
+		context("when the reload hook has an unknown signal", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"children": [{"name": "web", "command": ["sh"]}], "reload": {"signal": "SIGWINCH"}}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring(`has an unknown reload signal "SIGWINCH"`)))
+			})
+		})
+
+		context("when a hook has no command", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"children": [{"name": "web", "command": ["sh"], "pre": {"timeout": 1000}}]}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring(`has a hook without a command for child "web"`)))
+			})
+		})
+
+		context("when the log format is unknown", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"children": [{"name": "web", "command": ["sh"]}], "log_format": "xml"}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring(`has an unknown log format "xml"`)))
+			})
+		})
+
+		context("when a child has no command", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(path, []byte(`{"children": [{"name": "web"}]}`), 0600)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := launcher.Load(path)
+				Expect(err).To(MatchError(ContainSubstring(`has no command for child "web"`)))
+			})
+		})
+	})
+}
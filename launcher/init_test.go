@@ -0,0 +1,17 @@
+package launcher_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitLauncher(t *testing.T) {
+	suite := spec.New("launcher", spec.Report(report.Terminal{}), spec.Sequential())
+	suite("Config", testConfig)
+	suite("Launcher", testLauncher)
+	suite("Log", testLog)
+	suite("Wait", testWait)
+	suite.Run(t)
+}
@@ -0,0 +1,600 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// forwardedSignals are relayed from the launcher to every running child.
+var forwardedSignals = []os.Signal{
+	syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP, syscall.SIGQUIT,
+	syscall.SIGUSR1, syscall.SIGUSR2,
+}
+
+// signalNames maps the names of the forwarded signals to the signals.
+var signalNames = map[string]os.Signal{
+	"SIGTERM": syscall.SIGTERM,
+	"SIGINT":  syscall.SIGINT,
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+// Launcher supervises the children of a Config.
+type Launcher struct {
+	config Config
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func NewLauncher(config Config, stdout, stderr io.Writer) Launcher {
+	return Launcher{
+		config: config,
+		stdout: stdout,
+		stderr: stderr,
+	}
+}
+
+// supervisor tracks the state shared between the goroutines that run the
+// children.
+type supervisor struct {
+	mutex    sync.Mutex
+	commands map[int]*exec.Cmd
+	stopping bool
+	stopped  chan struct{}
+
+	// reloads holds, for each child that is being restarted in place, the
+	// WaitGroup to mark done once it has been started again.
+	reloads map[int]*sync.WaitGroup
+}
+
+// reload terminates every running child so that it is started again, and
+// returns a WaitGroup that is done once all of them have been restarted.
+func (s *supervisor) reload() *sync.WaitGroup {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var wg sync.WaitGroup
+	for i, command := range s.commands {
+		wg.Add(1)
+		s.reloads[i] = &wg
+		_ = command.Process.Signal(syscall.SIGTERM)
+	}
+
+	return &wg
+}
+
+// reloading returns the WaitGroup of a pending in-place restart of child i.
+func (s *supervisor) reloading(i int) *sync.WaitGroup {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	wg := s.reloads[i]
+	delete(s.reloads, i)
+
+	return wg
+}
+
+// signal delivers sig to every running child.
+func (s *supervisor) signal(sig os.Signal) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, command := range s.commands {
+		signalChild(command, sig)
+	}
+}
+
+// signalChild delivers sig to a child. Start scripts relay SIGTERM and SIGINT
+// to the app themselves, so those only go to the child, while the other
+// signals go to the process group of a child running in one of its own, so
+// that they reach the app instead of only killing the script in front of it.
+func signalChild(command *exec.Cmd, sig os.Signal) {
+	if sig == syscall.SIGTERM || sig == syscall.SIGINT || command.SysProcAttr == nil || !command.SysProcAttr.Setpgid {
+		_ = command.Process.Signal(sig)
+		return
+	}
+
+	_ = syscall.Kill(-command.Process.Pid, sig.(syscall.Signal))
+}
+
+// halt prevents further restarts.
+func (s *supervisor) halt() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.stopping {
+		s.stopping = true
+		close(s.stopped)
+	}
+}
+
+// stop prevents further restarts and delivers sig to every running child.
+func (s *supervisor) stop(sig os.Signal) {
+	s.halt()
+	s.signal(sig)
+}
+
+// start starts command as child i unless the supervisor is stopping.
+func (s *supervisor) start(i int, command *exec.Cmd) (bool, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.stopping {
+		return false, nil
+	}
+
+	err := command.Start()
+	if err != nil {
+		return false, err
+	}
+	s.commands[i] = command
+
+	return true, nil
+}
+
+func (s *supervisor) exited(i int) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.commands, i)
+}
+
+type result struct {
+	index int
+	code  int
+}
+
+// Run waits for the endpoints in WaitFor, if any, then starts every child
+// with args appended to its command, forwards the signals received by the
+// launcher to them and returns the exit code of the launcher. Children that
+// exit are restarted according to the restart policy. Once a child has
+// exited for good, its exit code is that of the launcher when KillOthers is
+// set, and otherwise the first non-zero exit code is returned once every
+// child has exited.
+func (l Launcher) Run(args []string) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, forwardedSignals...)
+	defer signal.Stop(signals)
+
+	if l.config.WaitFor != nil {
+		if code, ok := l.waitForEndpoints(signals); !ok {
+			return code
+		}
+	}
+
+	var (
+		mutex    sync.Mutex
+		flushers []*prefixWriter
+	)
+
+	prefixed := len(l.config.Children) > 1
+
+	s := &supervisor{
+		commands: map[int]*exec.Cmd{},
+		stopped:  make(chan struct{}),
+		reloads:  map[int]*sync.WaitGroup{},
+	}
+
+	results := make(chan result, len(l.config.Children))
+	for i, child := range l.config.Children {
+		stdout, stderr := l.stdout, l.stderr
+		if prefixed {
+			prefixedStdout := newPrefixWriter(fmt.Sprintf("[%s] ", child.Name), l.stdout, &mutex)
+			prefixedStderr := newPrefixWriter(fmt.Sprintf("[%s] ", child.Name), l.stderr, &mutex)
+			stdout, stderr = prefixedStdout, prefixedStderr
+			flushers = append(flushers, prefixedStdout, prefixedStderr)
+		}
+
+		go func(i int, child Child, stdout, stderr io.Writer) {
+			results <- result{index: i, code: l.supervise(s, i, child, args, stdout, stderr)}
+		}(i, child, stdout, stderr)
+	}
+
+	var (
+		shutdown     chan struct{}
+		childrenDone = make(chan struct{})
+		reloading    = make(chan struct{}, 1)
+	)
+
+	code := -1
+	for remaining := len(l.config.Children); remaining > 0; {
+		select {
+		case sig := <-signals:
+			l.event("signal_received", fields{"signal": signalName(sig)}, "")
+
+			if l.config.Reload != nil && sig == signalNames[l.config.Reload.Signal] {
+				// Signals that arrive while a restart is in progress are
+				// dropped rather than queued.
+				select {
+				case reloading <- struct{}{}:
+					go func() {
+						defer func() { <-reloading }()
+						l.reload(s)
+					}()
+				default:
+				}
+				continue
+			}
+
+			if sig == syscall.SIGTERM && l.config.Stop != nil && shutdown == nil {
+				s.halt()
+				shutdown = make(chan struct{})
+				go func() {
+					defer close(shutdown)
+					l.shutdown(s, childrenDone)
+				}()
+				continue
+			}
+
+			if sig == syscall.SIGTERM || sig == syscall.SIGINT {
+				s.stop(sig)
+			} else {
+				s.signal(sig)
+			}
+			l.event("signal_forwarded", fields{"signal": signalName(sig)}, "")
+
+		case r := <-results:
+			remaining--
+
+			if prefixed {
+				l.event("process_stopped", childFields(l.config.Children[r.index], fields{"code": r.code}), "[%s] exited with code %d", l.config.Children[r.index].Name, r.code)
+			} else {
+				l.event("process_stopped", childFields(l.config.Children[r.index], fields{"code": r.code}), "")
+			}
+
+			if code == -1 && (l.config.KillOthers || r.code != 0) {
+				code = r.code
+				if l.config.KillOthers {
+					s.stop(syscall.SIGTERM)
+				}
+			}
+		}
+	}
+
+	close(childrenDone)
+	if shutdown != nil {
+		<-shutdown
+	}
+
+	for _, flusher := range flushers {
+		_ = flusher.Flush()
+	}
+
+	return max(code, 0)
+}
+
+// shutdown runs the stop hook and terminates the children in the configured
+// order, within the timeout of the stop hook.
+func (l Launcher) shutdown(s *supervisor, childrenDone <-chan struct{}) {
+	stop := l.config.Stop
+	deadline := time.Now().Add(stop.Timeout)
+
+	switch stop.Order {
+	case StopAfter:
+		s.signal(syscall.SIGTERM)
+		select {
+		case <-childrenDone:
+		case <-time.After(time.Until(deadline)):
+		}
+		l.runStop(deadline)
+
+	case StopParallel:
+		s.signal(syscall.SIGTERM)
+		l.runStop(deadline)
+
+	default:
+		l.runStop(deadline)
+		s.signal(syscall.SIGTERM)
+	}
+}
+
+// reload runs the npm restart lifecycle in place.
+func (l Launcher) reload(s *supervisor) {
+	hook := l.config.Reload
+	l.event("restart_lifecycle", fields{"signal": hook.Signal}, "received %s, restarting", hook.Signal)
+
+	if len(hook.Command) > 0 {
+		l.runHook("restart", hook.Command, time.Time{})
+		return
+	}
+
+	if len(hook.Pre) > 0 {
+		l.runHook("prerestart", hook.Pre, time.Time{})
+	}
+
+	if l.config.Stop != nil {
+		l.runHook("stop", l.config.Stop.Command, time.Now().Add(l.config.Stop.Timeout))
+	}
+
+	s.reload().Wait()
+
+	if len(hook.Post) > 0 {
+		l.runHook("postrestart", hook.Post, time.Time{})
+	}
+}
+
+// runStop runs the stop hook, killing it at deadline.
+func (l Launcher) runStop(deadline time.Time) {
+	l.runHook("stop", l.config.Stop.Command, deadline)
+}
+
+// runHook runs the named npm lifecycle script, killing it at deadline unless
+// that is zero. Its output goes to the output of the launcher.
+func (l Launcher) runHook(name string, args []string, deadline time.Time) {
+	ctx, cancel := context.Background(), context.CancelFunc(func() {})
+	if !deadline.IsZero() {
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+	}
+	defer cancel()
+
+	command := exec.CommandContext(ctx, args[0], args[1:]...)
+	command.Stdout = l.stdout
+	command.Stderr = l.stderr
+	command.WaitDelay = time.Second
+
+	l.event("hook_started", fields{"script": name}, "running the %s script", name)
+	start := time.Now()
+	err := command.Run()
+	if ctx.Err() != nil {
+		timeout := deadline.Sub(start).Round(time.Millisecond)
+		l.event("hook_finished", fields{"script": name, "outcome": "timeout", "timeout": timeout.String()}, "the %s script did not finish within %s and was killed", name, timeout)
+		return
+	}
+
+	l.event("hook_finished", fields{"script": name, "outcome": "exited", "code": exitCode(err)}, "the %s script exited with code %d", name, exitCode(err))
+}
+
+// supervise runs child until it exits for good, restarting it according to
+// the restart policy, and returns its final exit code. Its pre hook runs
+// before every start, and its post hook after every successful exit.
+func (l Launcher) supervise(s *supervisor, i int, child Child, args []string, stdout, stderr io.Writer) int {
+	var reloaded *sync.WaitGroup
+
+	restarts := 0
+	for {
+		startedAt := time.Now()
+
+		// group is the process group of the child, once it has started.
+		group := 0
+
+		code, ok := l.runChildHook(s, i, child.Name, "prestart", child.Pre, stdout, stderr)
+		if ok {
+			command := exec.Command(child.Command[0], append(child.Command[1:], args...)...)
+			command.Stdin = os.Stdin
+			command.Stdout = stdout
+			command.Stderr = stderr
+
+			// Children run in a process group of their own, so that a start
+			// script relaying a signal to its group does not reach the
+			// launcher.
+			command.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+			// Processes left behind by the child may keep its output open,
+			// which must not keep the launcher waiting once it has exited.
+			command.WaitDelay = time.Second
+
+			started, err := s.start(i, command)
+			if reloaded != nil {
+				reloaded.Done()
+				reloaded = nil
+			}
+
+			if err != nil {
+				l.event("process_start_failed", childFields(child, fields{"error": err.Error()}), "failed to start %s: %s", child.Name, err)
+				return 127
+			}
+
+			if !started {
+				return 0
+			}
+
+			l.event("process_started", childFields(child, fields{"pid": command.Process.Pid}), "")
+
+			group = command.Process.Pid
+			code = exitCode(command.Wait())
+			s.exited(i)
+			l.event("process_exited", childFields(child, fields{"code": code}), "")
+
+			s.mutex.Lock()
+			stopping := s.stopping
+			s.mutex.Unlock()
+
+			if reloaded = s.reloading(i); reloaded != nil {
+				if stopping {
+					reloaded.Done()
+					return code
+				}
+
+				l.event("restart", childFields(child, fields{"code": code, "reason": "restart lifecycle"}), "%s exited with code %d, starting it again", child.Name, code)
+				killGroup(group)
+				continue
+			}
+
+			if code == 0 && !stopping {
+				code, _ = l.runChildHook(s, i, child.Name, "poststart", child.Post, stdout, stderr)
+			}
+		} else if reloaded != nil {
+			reloaded.Done()
+			reloaded = nil
+		}
+
+		s.mutex.Lock()
+		stopping := s.stopping
+		s.mutex.Unlock()
+
+		if stopping || !l.config.Restart.shouldRestart(code) {
+			return code
+		}
+
+		// A child that ran for a while before exiting is not crash looping,
+		// so it gets a fresh set of retries.
+		if time.Since(startedAt) >= l.config.Restart.resetAfter() {
+			restarts = 0
+		}
+
+		if l.config.Restart.MaxRetries > 0 && restarts >= l.config.Restart.MaxRetries {
+			l.event("restart_exhausted", childFields(child, fields{"code": code, "restarts": restarts}), "%s exited with code %d, giving up after %d restarts", child.Name, code, restarts)
+			return code
+		}
+
+		delay := l.config.Restart.delay(restarts)
+		restarts++
+		l.event("restart", childFields(child, fields{"code": code, "reason": "restart policy", "delay": delay.String(), "restart": restarts}), "%s exited with code %d, restarting in %s (restart %d)", child.Name, code, delay, restarts)
+
+		if group != 0 {
+			killGroup(group)
+		}
+
+		select {
+		case <-time.After(delay):
+		case <-s.stopped:
+			return code
+		}
+	}
+}
+
+// GroupGracePeriod is how long the processes left in the process group of an
+// exited child have to exit on SIGTERM before they are killed.
+const GroupGracePeriod = 5 * time.Second
+
+// killGroup terminates the processes left in the process group of an exited
+// child, such as an app whose start script was killed, so that the child is
+// never started again next to a copy of itself.
+func killGroup(group int) {
+	if syscall.Kill(-group, 0) != nil {
+		return
+	}
+
+	_ = syscall.Kill(-group, syscall.SIGTERM)
+	for deadline := time.Now().Add(GroupGracePeriod); time.Now().Before(deadline); {
+		time.Sleep(50 * time.Millisecond)
+		if syscall.Kill(-group, 0) != nil {
+			return
+		}
+	}
+
+	_ = syscall.Kill(-group, syscall.SIGKILL)
+}
+
+// HookTimeoutCode is the exit code of a hook that was killed because it did
+// not finish in time, following timeout(1).
+const HookTimeoutCode = 124
+
+// runChildHook runs the named hook of a child according to its failure
+// policy. It returns whether the child should carry on, and otherwise the
+// exit code of the failed hook. While it runs, the hook receives the signals
+// meant for the child.
+func (l Launcher) runChildHook(s *supervisor, i int, child, name string, hook *Hook, stdout, stderr io.Writer) (int, bool) {
+	if hook == nil {
+		return 0, true
+	}
+
+	code := 0
+	for attempt := 1; attempt <= hook.attempts(); attempt++ {
+		if attempt > 1 {
+			l.event("hook_retry", fields{"process": child, "script": name, "delay": hook.Delay.String(), "attempt": attempt, "attempts": hook.attempts()}, "retrying the %s script of %s in %s (attempt %d of %d)", name, child, hook.Delay, attempt, hook.attempts())
+			select {
+			case <-time.After(hook.Delay):
+			case <-s.stopped:
+				return code, false
+			}
+		}
+
+		ctx, cancel := context.Background(), context.CancelFunc(func() {})
+		if hook.Timeout > 0 {
+			ctx, cancel = context.WithTimeout(ctx, hook.Timeout)
+		}
+
+		command := exec.CommandContext(ctx, hook.Command[0], hook.Command[1:]...)
+		command.Stdout = stdout
+		command.Stderr = stderr
+		command.WaitDelay = time.Second
+
+		l.event("hook_started", fields{"process": child, "script": name}, "running the %s script of %s", name, child)
+		started, err := s.start(i, command)
+		if err != nil {
+			cancel()
+			l.event("hook_finished", fields{"process": child, "script": name, "outcome": "start failed", "error": err.Error()}, "failed to start the %s script of %s: %s", name, child, err)
+			code = 127
+			continue
+		}
+
+		if !started {
+			cancel()
+			return 0, false
+		}
+
+		err = command.Wait()
+		s.exited(i)
+		cancel()
+
+		// The child is started again right after its hook, so a reload that
+		// interrupted the hook is complete.
+		if wg := s.reloading(i); wg != nil {
+			wg.Done()
+		}
+
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			code = HookTimeoutCode
+			l.event("hook_finished", fields{"process": child, "script": name, "outcome": "timeout", "timeout": hook.Timeout.String()}, "the %s script of %s did not finish within %s and was killed", name, child, hook.Timeout)
+		default:
+			code = exitCode(err)
+			if code == 0 {
+				l.event("hook_finished", fields{"process": child, "script": name, "outcome": "succeeded", "code": 0}, "the %s script of %s succeeded", name, child)
+				return 0, true
+			}
+			l.event("hook_finished", fields{"process": child, "script": name, "outcome": "failed", "code": code}, "the %s script of %s failed with code %d", name, child, code)
+		}
+
+		s.mutex.Lock()
+		stopping := s.stopping
+		s.mutex.Unlock()
+
+		if stopping {
+			return code, false
+		}
+	}
+
+	if hook.OnFailure == HookContinue {
+		l.event("hook_failure", fields{"process": child, "script": name, "policy": HookContinue}, "continuing with %s despite the failed %s script", child, name)
+		return 0, true
+	}
+
+	if hook.OnFailure == HookRetry {
+		l.event("hook_failure", fields{"process": child, "script": name, "policy": HookRetry, "code": code}, "the %s script of %s failed %d times, stopping %s", name, child, hook.attempts(), child)
+	} else {
+		l.event("hook_failure", fields{"process": child, "script": name, "policy": HookFail, "code": code}, "the %s script of %s failed, stopping %s", name, child, child)
+	}
+
+	return code, false
+}
+
+// exitCode converts the error returned by exec.Cmd.Wait into an exit code,
+// following the shell convention of 128 plus the signal number for children
+// that were killed by a signal.
+func exitCode(err error) int {
+	if err == nil {
+		return 0
+	}
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		return exitErr.ExitCode()
+	}
+
+	return 1
+}
@@ -0,0 +1,514 @@
+package launcher_test
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+// syncBuffer is a bytes.Buffer that is safe to write to from the goroutines
+// that copy the output of a child.
+type syncBuffer struct {
+	mutex  sync.Mutex
+	buffer bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+	return b.buffer.String()
+}
+
+func testLauncher(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect     = NewWithT(t).Expect
+		Eventually = NewWithT(t).Eventually
+
+		stdout *syncBuffer
+		stderr *syncBuffer
+	)
+
+	it.Before(func() {
+		stdout = &syncBuffer{}
+		stderr = &syncBuffer{}
+	})
+
+	context("with a single child", func() {
+		it("passes the arguments on, does not prefix the output and returns its exit code", func() {
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: []string{"sh", "-c", `echo "started $1"; echo oops >&2; exit 3`, "sh"}},
+				},
+			}, stdout, stderr).Run([]string{"--port=8080"})
+
+			Expect(code).To(Equal(3))
+			Expect(stdout.String()).To(Equal("started --port=8080\n"))
+			Expect(stderr.String()).To(Equal("oops\n"))
+		})
+
+		it("returns 127 when the command cannot be started", func() {
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: []string{filepath.Join(t.TempDir(), "missing")}},
+				},
+			}, stdout, stderr).Run(nil)
+
+			Expect(code).To(Equal(127))
+			Expect(stderr.String()).To(ContainSubstring("failed to start web"))
+		})
+
+		it("forwards signals", func() {
+			ready := filepath.Join(t.TempDir(), "ready")
+
+			codes := make(chan int)
+			go func() {
+				codes <- launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `trap 'echo terminated; exit 0' TERM; touch "$0"; while :; do sleep 0.05; done`, ready}},
+					},
+				}, stdout, stderr).Run(nil)
+			}()
+
+			Eventually(func() error { _, err := os.Stat(ready); return err }).Should(Succeed())
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGTERM)).To(Succeed())
+
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+			Expect(stdout.String()).To(ContainSubstring("terminated"))
+		})
+
+		it("forwards the other signals to the process group of the child", func() {
+			dir := t.TempDir()
+			events := filepath.Join(dir, "events")
+			ready := filepath.Join(dir, "ready")
+
+			codes := make(chan int)
+			go func() {
+				codes <- launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `sh -c 'trap "echo usr1 >> \"\$0\"; exit 0" USR1; touch "$1"; while :; do sleep 0.05; done' "$0" "$1"; echo unreachable`, events, ready}},
+					},
+				}, stdout, stderr).Run(nil)
+			}()
+
+			Eventually(func() error { _, err := os.Stat(ready); return err }).Should(Succeed())
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGUSR1)).To(Succeed())
+
+			Eventually(codes, "5s").Should(Receive(Equal(128 + int(syscall.SIGUSR1))))
+			Eventually(func() (string, error) { content, err := os.ReadFile(events); return string(content), err }).Should(Equal("usr1\n"))
+			Expect(stdout.String()).NotTo(ContainSubstring("unreachable"))
+		})
+	})
+
+	context("with a restart policy", func() {
+		var counter string
+
+		it.Before(func() {
+			counter = filepath.Join(t.TempDir(), "counter")
+		})
+
+		runs := func() int {
+			content, err := os.ReadFile(counter)
+			Expect(err).NotTo(HaveOccurred())
+			return len(content)
+		}
+
+		context("when the policy is on-failure", func() {
+			it("restarts a failing child with exponential backoff until the retries are exhausted", func() {
+				code := launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `printf x >> "$0"; exit 3`, counter}},
+					},
+					Restart: launcher.RestartPolicy{
+						Policy:     launcher.RestartOnFailure,
+						MaxRetries: 2,
+						Backoff:    10 * time.Millisecond,
+					},
+				}, stdout, stderr).Run(nil)
+
+				Expect(code).To(Equal(3))
+				Expect(runs()).To(Equal(3))
+				Expect(stderr.String()).To(ContainSubstring("web exited with code 3, restarting in 10ms (restart 1)"))
+				Expect(stderr.String()).To(ContainSubstring("web exited with code 3, restarting in 20ms (restart 2)"))
+				Expect(stderr.String()).To(ContainSubstring("web exited with code 3, giving up after 2 restarts"))
+			})
+
+			it("does not restart a child that exits successfully", func() {
+				code := launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `printf x >> "$0"`, counter}},
+					},
+					Restart: launcher.RestartPolicy{
+						Policy:     launcher.RestartOnFailure,
+						MaxRetries: 2,
+						Backoff:    10 * time.Millisecond,
+					},
+				}, stdout, stderr).Run(nil)
+
+				Expect(code).To(Equal(0))
+				Expect(runs()).To(Equal(1))
+			})
+		})
+
+		context("when the policy is always", func() {
+			it("restarts a child that exits successfully", func() {
+				code := launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `printf x >> "$0"`, counter}},
+					},
+					Restart: launcher.RestartPolicy{
+						Policy:     launcher.RestartAlways,
+						MaxRetries: 1,
+						Backoff:    10 * time.Millisecond,
+					},
+				}, stdout, stderr).Run(nil)
+
+				Expect(code).To(Equal(0))
+				Expect(runs()).To(Equal(2))
+			})
+		})
+
+		it("kills what is left of the process group of the child before restarting it", func() {
+			pids := filepath.Join(t.TempDir(), "pids")
+
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: []string{"sh", "-c", `for pid in $(cat "$0" 2>/dev/null); do kill -0 "$pid" 2>/dev/null && echo "$pid is still running"; done; sleep 30 & echo "$!" >> "$0"; exit 3`, pids}},
+				},
+				Restart: launcher.RestartPolicy{
+					Policy:     launcher.RestartOnFailure,
+					MaxRetries: 1,
+					Backoff:    10 * time.Millisecond,
+				},
+			}, stdout, stderr).Run(nil)
+
+			content, err := os.ReadFile(pids)
+			Expect(err).NotTo(HaveOccurred())
+			for _, pid := range strings.Fields(string(content)) {
+				var p int
+				_, err := fmt.Sscan(pid, &p)
+				Expect(err).NotTo(HaveOccurred())
+				_ = syscall.Kill(p, syscall.SIGKILL)
+			}
+
+			Expect(code).To(Equal(3))
+			Expect(strings.Fields(string(content))).To(HaveLen(2))
+			Expect(stdout.String()).NotTo(ContainSubstring("is still running"))
+		})
+
+		it("stops restarting when the launcher is terminated", func() {
+			codes := make(chan int)
+			go func() {
+				codes <- launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `printf x >> "$0"; exit 1`, counter}},
+					},
+					Restart: launcher.RestartPolicy{
+						Policy:  launcher.RestartAlways,
+						Backoff: time.Minute,
+					},
+				}, stdout, stderr).Run(nil)
+			}()
+
+			Eventually(stderr.String).Should(ContainSubstring("restarting in 1m0s"))
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGTERM)).To(Succeed())
+
+			Eventually(codes, "5s").Should(Receive(Equal(1)))
+			Expect(runs()).To(Equal(1))
+		})
+	})
+
+	context("with a stop hook", func() {
+		var (
+			dir    string
+			events string
+			ready  string
+			run    func(launcher.StopHook) chan int
+		)
+
+		it.Before(func() {
+			dir = t.TempDir()
+			events = filepath.Join(dir, "events")
+			ready = filepath.Join(dir, "ready")
+
+			run = func(stop launcher.StopHook) chan int {
+				codes := make(chan int)
+				go func() {
+					codes <- launcher.NewLauncher(launcher.Config{
+						Children: []launcher.Child{
+							{Name: "web", Command: []string{"sh", "-c", `trap 'echo terminated >> "$0"; exit 0' TERM; touch "$1"; while :; do sleep 0.05; done`, events, ready}},
+						},
+						Stop: &stop,
+					}, stdout, stderr).Run(nil)
+				}()
+
+				Eventually(func() error { _, err := os.Stat(ready); return err }).Should(Succeed())
+				Expect(syscall.Kill(os.Getpid(), syscall.SIGTERM)).To(Succeed())
+
+				return codes
+			}
+		})
+
+		eventLog := func() string {
+			content, err := os.ReadFile(events)
+			Expect(err).NotTo(HaveOccurred())
+			return string(content)
+		}
+
+		it("runs the stop script before terminating the children by default", func() {
+			codes := run(launcher.StopHook{
+				Command: []string{"sh", "-c", `echo stopping; echo stopped >> "$0"`, events},
+				Timeout: 5 * time.Second,
+			})
+
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+			Expect(eventLog()).To(Equal("stopped\nterminated\n"))
+			Expect(stdout.String()).To(ContainSubstring("stopping"))
+			Expect(stderr.String()).To(ContainSubstring("running the stop script"))
+			Expect(stderr.String()).To(ContainSubstring("the stop script exited with code 0"))
+		})
+
+		it("runs the stop script after the children have exited", func() {
+			codes := run(launcher.StopHook{
+				Command: []string{"sh", "-c", `echo stopped >> "$0"`, events},
+				Order:   launcher.StopAfter,
+				Timeout: 5 * time.Second,
+			})
+
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+			Expect(eventLog()).To(Equal("terminated\nstopped\n"))
+		})
+
+		it("kills the stop script when it does not finish in time", func() {
+			start := time.Now()
+			codes := run(launcher.StopHook{
+				Command: []string{"sleep", "30"},
+				Timeout: 200 * time.Millisecond,
+			})
+
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			Expect(eventLog()).To(Equal("terminated\n"))
+			Expect(stderr.String()).To(ContainSubstring("the stop script did not finish within 200ms and was killed"))
+		})
+	})
+
+	context("with a reload hook", func() {
+		var (
+			dir    string
+			events string
+			ready  string
+		)
+
+		it.Before(func() {
+			dir = t.TempDir()
+			events = filepath.Join(dir, "events")
+			ready = filepath.Join(dir, "ready")
+		})
+
+		run := func(config launcher.Config) chan int {
+			config.Children = []launcher.Child{
+				{Name: "web", Command: []string{"sh", "-c", `echo started >> "$0"; trap 'echo terminated >> "$0"; exit 0' TERM; touch "$1"; while :; do sleep 0.05; done`, events, ready}},
+			}
+
+			codes := make(chan int)
+			go func() {
+				codes <- launcher.NewLauncher(config, stdout, stderr).Run(nil)
+			}()
+
+			Eventually(func() error { _, err := os.Stat(ready); return err }).Should(Succeed())
+
+			return codes
+		}
+
+		eventLog := func() string {
+			content, err := os.ReadFile(events)
+			if err != nil {
+				return ""
+			}
+			return string(content)
+		}
+
+		it("runs the restart script without restarting the children", func() {
+			codes := run(launcher.Config{
+				Reload: &launcher.ReloadHook{
+					Signal:  "SIGHUP",
+					Command: []string{"sh", "-c", `echo restarted >> "$0"`, events},
+				},
+			})
+
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGHUP)).To(Succeed())
+			Eventually(stderr.String).Should(ContainSubstring("the restart script exited with code 0"))
+			Expect(eventLog()).To(Equal("started\nrestarted\n"))
+
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGTERM)).To(Succeed())
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+			Expect(stderr.String()).To(ContainSubstring("received SIGHUP, restarting"))
+		})
+
+		it("stops and starts the children between the prerestart and postrestart scripts", func() {
+			codes := run(launcher.Config{
+				Stop: &launcher.StopHook{
+					Command: []string{"sh", "-c", `echo stopped >> "$0"`, events},
+					Timeout: 5 * time.Second,
+				},
+				Reload: &launcher.ReloadHook{
+					Signal: "SIGUSR1",
+					Pre:    []string{"sh", "-c", `echo prerestart >> "$0"`, events},
+					Post:   []string{"sh", "-c", `echo postrestart >> "$0"`, events},
+				},
+			})
+
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGUSR1)).To(Succeed())
+			Eventually(stderr.String, "5s").Should(ContainSubstring("the postrestart script exited with code 0"))
+			Expect(eventLog()).To(HavePrefix("started\nprerestart\nstopped\nterminated\n"))
+			Expect(eventLog()).To(ContainSubstring("postrestart\n"))
+			Eventually(func() int { return strings.Count(eventLog(), "started") }).Should(Equal(2))
+			Expect(stderr.String()).To(ContainSubstring("web exited with code 0, starting it again"))
+
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGTERM)).To(Succeed())
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+		})
+	})
+
+	context("with prestart and poststart hooks", func() {
+		var (
+			dir    string
+			events string
+		)
+
+		it.Before(func() {
+			dir = t.TempDir()
+			events = filepath.Join(dir, "events")
+		})
+
+		record := func(event string) []string {
+			return []string{"sh", "-c", fmt.Sprintf(`echo %s >> "$0"`, event), events}
+		}
+
+		run := func(pre, post *launcher.Hook) int {
+			return launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: record("start"), Pre: pre, Post: post},
+				},
+			}, stdout, stderr).Run(nil)
+		}
+
+		eventLog := func() string {
+			content, err := os.ReadFile(events)
+			if err != nil {
+				return ""
+			}
+			return string(content)
+		}
+
+		it("runs them around the child", func() {
+			Expect(run(&launcher.Hook{Command: record("prestart")}, &launcher.Hook{Command: record("poststart")})).To(Equal(0))
+
+			Expect(eventLog()).To(Equal("prestart\nstart\npoststart\n"))
+			Expect(stderr.String()).To(ContainSubstring("running the prestart script of web"))
+			Expect(stderr.String()).To(ContainSubstring("the prestart script of web succeeded"))
+			Expect(stderr.String()).To(ContainSubstring("the poststart script of web succeeded"))
+		})
+
+		it("fails the child when a hook does not finish in time", func() {
+			start := time.Now()
+			Expect(run(&launcher.Hook{Command: []string{"sleep", "30"}, Timeout: 100 * time.Millisecond, OnFailure: launcher.HookFail}, nil)).To(Equal(launcher.HookTimeoutCode))
+
+			Expect(time.Since(start)).To(BeNumerically("<", 5*time.Second))
+			Expect(eventLog()).To(BeEmpty())
+			Expect(stderr.String()).To(ContainSubstring("the prestart script of web did not finish within 100ms and was killed"))
+			Expect(stderr.String()).To(ContainSubstring("the prestart script of web failed, stopping web"))
+		})
+
+		it("continues when a failing hook is allowed to fail", func() {
+			Expect(run(&launcher.Hook{Command: []string{"sh", "-c", "exit 3"}, OnFailure: launcher.HookContinue}, nil)).To(Equal(0))
+
+			Expect(eventLog()).To(Equal("start\n"))
+			Expect(stderr.String()).To(ContainSubstring("the prestart script of web failed with code 3"))
+			Expect(stderr.String()).To(ContainSubstring("continuing with web despite the failed prestart script"))
+		})
+
+		it("retries a failing hook", func() {
+			counter := filepath.Join(dir, "counter")
+			flaky := []string{"sh", "-c", `printf x >> "$0"; [ "$(cat "$0")" = xxx ]`, counter}
+
+			Expect(run(&launcher.Hook{Command: flaky, OnFailure: launcher.HookRetry, Retries: 3, Delay: 10 * time.Millisecond}, nil)).To(Equal(0))
+
+			Expect(eventLog()).To(Equal("start\n"))
+			Expect(stderr.String()).To(ContainSubstring("retrying the prestart script of web in 10ms (attempt 3 of 4)"))
+			Expect(stderr.String()).NotTo(ContainSubstring("attempt 4 of 4"))
+		})
+
+		it("fails the child once the retries are exhausted", func() {
+			Expect(run(nil, &launcher.Hook{Command: []string{"sh", "-c", "exit 2"}, OnFailure: launcher.HookRetry, Retries: 1})).To(Equal(2))
+
+			Expect(eventLog()).To(Equal("start\n"))
+			Expect(stderr.String()).To(ContainSubstring("the poststart script of web failed 2 times, stopping web"))
+		})
+	})
+
+	context("with several children", func() {
+		it("prefixes each line of output with the name of the child", func() {
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: []string{"sh", "-c", `echo one; printf partial`}},
+					{Name: "worker", Command: []string{"sh", "-c", `echo two >&2`}},
+				},
+			}, stdout, stderr).Run(nil)
+
+			Expect(code).To(Equal(0))
+			Expect(stdout.String()).To(ContainSubstring("[web] one\n"))
+			Expect(stdout.String()).To(ContainSubstring("[web] partial\n"))
+			Expect(stderr.String()).To(ContainSubstring("[worker] two\n"))
+			Expect(stderr.String()).To(ContainSubstring("[worker] exited with code 0\n"))
+		})
+
+		context("when KillOthers is set", func() {
+			it("stops the others when one exits and returns its exit code", func() {
+				start := time.Now()
+				code := launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `sleep 0.1; exit 4`}},
+						{Name: "worker", Command: []string{"sleep", "30"}},
+					},
+					KillOthers: true,
+				}, stdout, stderr).Run(nil)
+
+				Expect(code).To(Equal(4))
+				Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+				Expect(stderr.String()).To(ContainSubstring("[worker] exited with code 143"))
+			})
+		})
+
+		context("when KillOthers is not set", func() {
+			it("waits for every child and returns the first non-zero exit code", func() {
+				code := launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `exit 5`}},
+						{Name: "worker", Command: []string{"sh", "-c", `sleep 0.2; echo done`}},
+					},
+				}, stdout, stderr).Run(nil)
+
+				Expect(code).To(Equal(5))
+				Expect(stdout.String()).To(ContainSubstring("[worker] done"))
+			})
+		})
+	})
+}
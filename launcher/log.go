@@ -0,0 +1,73 @@
+package launcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// fields are the details of a lifecycle event, such as the process or script
+// it concerns.
+type fields map[string]any
+
+// childFields adds the name and npm script of child, which identify it in
+// lifecycle events, to details.
+func childFields(child Child, details fields) fields {
+	details["process"] = child.Name
+	if child.Script != "" {
+		details["script"] = child.Script
+	}
+
+	return details
+}
+
+// event reports a lifecycle event of the launcher. In the text format, only
+// the message is written, on a line of its own, and events without a message
+// are left out. In the JSON format, every event is written as an object with
+// the time, the name of the event, the message and the fields.
+func (l Launcher) event(name string, details fields, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+
+	if l.config.LogFormat != LogFormatJSON {
+		if message != "" {
+			fmt.Fprintln(l.stderr, message)
+		}
+		return
+	}
+
+	entry := map[string]any{
+		"time":  time.Now().UTC().Format(time.RFC3339Nano),
+		"event": name,
+	}
+	if message != "" {
+		entry["message"] = message
+	}
+	for key, value := range details {
+		entry[key] = value
+	}
+
+	content, err := json.Marshal(entry)
+	if err != nil {
+		fmt.Fprintf(l.stderr, "%s: %s\n", name, message)
+		return
+	}
+
+	_, _ = l.stderr.Write(append(content, '\n'))
+}
+
+// signalName returns the name, such as SIGTERM, of sig.
+func signalName(sig os.Signal) string {
+	for name, known := range signalNames {
+		if known == sig {
+			return name
+		}
+	}
+
+	return sig.String()
+}
@@ -0,0 +1,131 @@
+package launcher_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testLog(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect     = NewWithT(t).Expect
+		Eventually = NewWithT(t).Eventually
+
+		stdout *syncBuffer
+		stderr *syncBuffer
+	)
+
+	it.Before(func() {
+		stdout = &syncBuffer{}
+		stderr = &syncBuffer{}
+	})
+
+	events := func() []map[string]any {
+		var entries []map[string]any
+		for _, line := range strings.Split(strings.TrimSpace(stderr.String()), "\n") {
+			var entry map[string]any
+			Expect(json.Unmarshal([]byte(line), &entry)).To(Succeed(), line)
+
+			_, err := time.Parse(time.RFC3339Nano, entry["time"].(string))
+			Expect(err).NotTo(HaveOccurred())
+			delete(entry, "time")
+			delete(entry, "pid")
+
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+
+	context("with the JSON log format", func() {
+		it("reports every lifecycle event as a JSON object", func() {
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{
+						Name:    "web",
+						Command: []string{"sh", "-c", "echo output; exit 3"},
+						Pre:     &launcher.Hook{Command: []string{"true"}},
+					},
+				},
+				LogFormat: launcher.LogFormatJSON,
+			}, stdout, stderr).Run(nil)
+			Expect(code).To(Equal(3))
+
+			Expect(stdout.String()).To(Equal("output\n"))
+			Expect(events()).To(Equal([]map[string]any{
+				{"event": "hook_started", "process": "web", "script": "prestart", "message": "running the prestart script of web"},
+				{"event": "hook_finished", "process": "web", "script": "prestart", "outcome": "succeeded", "code": 0.0, "message": "the prestart script of web succeeded"},
+				{"event": "process_started", "process": "web"},
+				{"event": "process_exited", "process": "web", "code": 3.0},
+				{"event": "process_stopped", "process": "web", "code": 3.0},
+			}))
+		})
+
+		it("reports the npm script of each process", func() {
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{
+						Name:    "web",
+						Script:  "dev",
+						Command: []string{"sh", "-c", "exit 0"},
+						Pre:     &launcher.Hook{Command: []string{"false"}},
+					},
+				},
+				LogFormat: launcher.LogFormatJSON,
+			}, stdout, stderr).Run(nil)
+			Expect(code).To(Equal(1))
+
+			Expect(events()).To(Equal([]map[string]any{
+				{"event": "hook_started", "process": "web", "script": "prestart", "message": "running the prestart script of web"},
+				{"event": "hook_finished", "process": "web", "script": "prestart", "outcome": "failed", "code": 1.0, "message": "the prestart script of web failed with code 1"},
+				{"event": "hook_failure", "process": "web", "script": "prestart", "policy": "fail", "code": 1.0, "message": "the prestart script of web failed, stopping web"},
+				{"event": "process_stopped", "process": "web", "script": "dev", "code": 1.0},
+			}))
+		})
+
+		it("reports the signals it receives and forwards", func() {
+			ready := filepath.Join(t.TempDir(), "ready")
+
+			codes := make(chan int)
+			go func() {
+				codes <- launcher.NewLauncher(launcher.Config{
+					Children: []launcher.Child{
+						{Name: "web", Command: []string{"sh", "-c", `trap 'exit 0' TERM; touch "$0"; while :; do sleep 0.05; done`, ready}},
+					},
+					LogFormat: launcher.LogFormatJSON,
+				}, stdout, stderr).Run(nil)
+			}()
+
+			Eventually(func() error { _, err := os.Stat(ready); return err }).Should(Succeed())
+			Expect(syscall.Kill(os.Getpid(), syscall.SIGTERM)).To(Succeed())
+			Eventually(codes, "5s").Should(Receive(Equal(0)))
+
+			Expect(events()).To(ContainElements(
+				map[string]any{"event": "signal_received", "signal": "SIGTERM"},
+				map[string]any{"event": "signal_forwarded", "signal": "SIGTERM"},
+				map[string]any{"event": "process_exited", "process": "web", "code": 0.0},
+			))
+		})
+	})
+
+	context("with the text log format", func() {
+		it("only writes the events that have a message", func() {
+			code := launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: []string{"sh", "-c", "exit 3"}},
+				},
+			}, stdout, stderr).Run(nil)
+			Expect(code).To(Equal(3))
+
+			Expect(stderr.String()).To(BeEmpty())
+		})
+	})
+}
@@ -0,0 +1,64 @@
+package launcher
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// prefixWriter writes complete lines to w, each preceded by prefix. Partial
+// lines are buffered until they are completed or the writer is flushed, so
+// that the output of several children is not interleaved mid-line.
+type prefixWriter struct {
+	prefix []byte
+	w      io.Writer
+	mutex  *sync.Mutex
+	buffer []byte
+}
+
+func newPrefixWriter(prefix string, w io.Writer, mutex *sync.Mutex) *prefixWriter {
+	return &prefixWriter{
+		prefix: []byte(prefix),
+		w:      w,
+		mutex:  mutex,
+	}
+}
+
+func (p *prefixWriter) Write(data []byte) (int, error) {
+	p.buffer = append(p.buffer, data...)
+
+	for {
+		i := bytes.IndexByte(p.buffer, '\n')
+		if i < 0 {
+			break
+		}
+
+		err := p.writeLine(p.buffer[:i+1])
+		if err != nil {
+			return 0, err
+		}
+		p.buffer = p.buffer[i+1:]
+	}
+
+	return len(data), nil
+}
+
+// Flush writes a trailing partial line, terminating it with a newline.
+func (p *prefixWriter) Flush() error {
+	if len(p.buffer) == 0 {
+		return nil
+	}
+
+	line := append(p.buffer, '\n')
+	p.buffer = nil
+
+	return p.writeLine(line)
+}
+
+func (p *prefixWriter) writeLine(line []byte) error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	_, err := p.w.Write(append(append([]byte{}, p.prefix...), line...))
+	return err
+}
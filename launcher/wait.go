@@ -0,0 +1,186 @@
+package launcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultWaitInterval is the time between two checks of an endpoint.
+	DefaultWaitInterval = time.Second
+
+	// waitProgressInterval is how often the launcher reports that it is
+	// still waiting for an endpoint.
+	waitProgressInterval = 10 * time.Second
+)
+
+// WaitFor holds back the children until every endpoint accepts connections,
+// for at most Timeout.
+type WaitFor struct {
+	// Endpoints are URLs such as tcp://db:5432 or http://auth/health.
+	Endpoints []string      `json:"endpoints"`
+	Timeout   time.Duration `json:"timeout"`
+	Interval  time.Duration `json:"interval,omitempty"`
+}
+
+// ParseEndpoint parses an endpoint to wait for, which is either a tcp URL
+// with a host and port, or an http or https URL.
+func ParseEndpoint(endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	switch u.Scheme {
+	case "tcp":
+		if u.Hostname() == "" || u.Port() == "" {
+			return nil, fmt.Errorf("%s must have a host and port", endpoint)
+		}
+	case "http", "https":
+		if u.Host == "" {
+			return nil, fmt.Errorf("%s must have a host", endpoint)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be a tcp, http or https URL", endpoint)
+	}
+
+	return u, nil
+}
+
+// Probe checks once, within timeout, whether endpoint is ready. TCP
+// endpoints are ready when they accept a connection, and HTTP endpoints when
+// they answer with a status below 400.
+func Probe(ctx context.Context, endpoint *url.URL, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if endpoint.Scheme == "tcp" {
+		var dialer net.Dialer
+		conn, err := dialer.DialContext(ctx, "tcp", endpoint.Host)
+		if err != nil {
+			return err
+		}
+		return conn.Close()
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode >= 400 {
+		return fmt.Errorf("status %s", response.Status)
+	}
+
+	return nil
+}
+
+// wait polls every endpoint until all of them are ready, reporting progress,
+// and fails when that takes longer than the timeout or ctx is cancelled. The
+// error lists every endpoint that was not ready.
+func (l Launcher) wait(ctx context.Context) error {
+	wait := l.config.WaitFor
+
+	interval := wait.Interval
+	if interval <= 0 {
+		interval = DefaultWaitInterval
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, wait.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	errs := make(chan error, len(wait.Endpoints))
+
+	var wg sync.WaitGroup
+	for _, endpoint := range wait.Endpoints {
+		u, err := ParseEndpoint(endpoint)
+		if err != nil {
+			return err
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			l.event("wait_started", fields{"endpoint": endpoint}, "waiting for %s", endpoint)
+			lastReport := start
+			for {
+				err := Probe(ctx, u, interval)
+				if err == nil {
+					elapsed := time.Since(start).Round(time.Millisecond)
+					l.event("wait_ready", fields{"endpoint": endpoint, "elapsed": elapsed.String()}, "%s is ready after %s", endpoint, elapsed)
+					return
+				}
+
+				if time.Since(lastReport) >= waitProgressInterval {
+					elapsed := time.Since(start).Round(time.Second)
+					l.event("wait_progress", fields{"endpoint": endpoint, "elapsed": elapsed.String(), "error": err.Error()}, "still waiting for %s after %s: %s", endpoint, elapsed, err)
+					lastReport = time.Now()
+				}
+
+				select {
+				case <-time.After(interval):
+				case <-ctx.Done():
+					errs <- fmt.Errorf("%s was not ready within %s: %w", endpoint, wait.Timeout, err)
+					return
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+	close(errs)
+
+	var failures []error
+	for err := range errs {
+		failures = append(failures, err)
+	}
+
+	return errors.Join(failures...)
+}
+
+// waitForEndpoints runs wait until it finishes or the launcher is asked to
+// stop, and returns whether the children should be started and otherwise the
+// exit code of the launcher.
+func (l Launcher) waitForEndpoints(signals <-chan os.Signal) (int, bool) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- l.wait(ctx)
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			if err != nil {
+				l.event("wait_failed", fields{"error": err.Error()}, "not starting: %s", err)
+				return 1, false
+			}
+			return 0, true
+
+		case sig := <-signals:
+			if sig == syscall.SIGTERM || sig == syscall.SIGINT {
+				cancel()
+				<-done
+				return 128 + int(sig.(syscall.Signal)), false
+			}
+		}
+	}
+}
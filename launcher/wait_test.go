@@ -0,0 +1,109 @@
+package launcher_test
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testWait(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		stdout  *syncBuffer
+		stderr  *syncBuffer
+		started string
+		run     func(launcher.WaitFor) int
+	)
+
+	it.Before(func() {
+		stdout = &syncBuffer{}
+		stderr = &syncBuffer{}
+		started = filepath.Join(t.TempDir(), "started")
+
+		run = func(wait launcher.WaitFor) int {
+			return launcher.NewLauncher(launcher.Config{
+				Children: []launcher.Child{
+					{Name: "web", Command: []string{"touch", started}},
+				},
+				WaitFor: &wait,
+			}, stdout, stderr).Run(nil)
+		}
+	})
+
+	it("starts the children once every endpoint is ready", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		defer listener.Close()
+
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/health" || requests.Add(1) < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		}))
+		defer server.Close()
+
+		tcp := fmt.Sprintf("tcp://%s", listener.Addr())
+		health := fmt.Sprintf("%s/health", server.URL)
+
+		Expect(run(launcher.WaitFor{
+			Endpoints: []string{tcp, health},
+			Timeout:   5 * time.Second,
+			Interval:  20 * time.Millisecond,
+		})).To(Equal(0))
+
+		Expect(started).To(BeAnExistingFile())
+		Expect(requests.Load()).To(BeEquivalentTo(3))
+		Expect(stderr.String()).To(ContainSubstring(fmt.Sprintf("waiting for %s", tcp)))
+		Expect(stderr.String()).To(ContainSubstring(fmt.Sprintf("%s is ready after", tcp)))
+		Expect(stderr.String()).To(ContainSubstring(fmt.Sprintf("%s is ready after", health)))
+	})
+
+	it("does not start the children when an endpoint is not ready in time", func() {
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		Expect(err).NotTo(HaveOccurred())
+		tcp := fmt.Sprintf("tcp://%s", listener.Addr())
+		Expect(listener.Close()).To(Succeed())
+
+		Expect(run(launcher.WaitFor{
+			Endpoints: []string{tcp},
+			Timeout:   200 * time.Millisecond,
+			Interval:  20 * time.Millisecond,
+		})).To(Equal(1))
+
+		_, err = os.Stat(started)
+		Expect(os.IsNotExist(err)).To(BeTrue())
+		Expect(stderr.String()).To(ContainSubstring(fmt.Sprintf("not starting: %s was not ready within 200ms", tcp)))
+	})
+
+	context("ParseEndpoint", func() {
+		it("accepts tcp, http and https URLs", func() {
+			for _, endpoint := range []string{"tcp://db:5432", "http://auth/health", "https://auth:8443"} {
+				_, err := launcher.ParseEndpoint(endpoint)
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		it("rejects a tcp URL without a port", func() {
+			_, err := launcher.ParseEndpoint("tcp://db")
+			Expect(err).To(MatchError("tcp://db must have a host and port"))
+		})
+
+		it("rejects other schemes", func() {
+			_, err := launcher.ParseEndpoint("redis://cache:6379")
+			Expect(err).To(MatchError("redis://cache:6379 must be a tcp, http or https URL"))
+		})
+	})
+}
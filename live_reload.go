@@ -0,0 +1,317 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/reload"
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+var validExtension = regexp.MustCompile(`^[A-Za-z0-9]+$`)
+
+// generatedIgnores are the package manager files, dependency directories and
+// files written by this buildpack that never trigger a reload.
+var generatedIgnores = []string{
+	"package.json",
+	"package-lock.json",
+	"npm-shrinkwrap.json",
+	"yarn.lock",
+	"pnpm-lock.yaml",
+	"node_modules",
+	"start.sh",
+	"reinstall.sh",
+}
+
+// reloadableProcessSpec resolves the live reload configuration for the app in
+// projectPath. BP_LIVE_RELOAD_WATCH_PATHS replaces the default of watching
+// the whole project, BP_LIVE_RELOAD_IGNORE_PATHS adds to the files that are
+// always ignored, and BP_LIVE_RELOAD_EXTENSIONS restricts the watched file
+// types. Paths are comma-separated and relative to the project path. The
+// patterns in the project's .gitignore and .dockerignore are ignored as well.
+// BP_LIVE_RELOAD_SIGNAL, BP_LIVE_RELOAD_DEBOUNCE and BP_LIVE_RELOAD_MODE
+// control how the reloader reacts to a change. The node backend is resolved
+// by nodeReloadableProcessSpec.
+func reloadableProcessSpec(projectPath string) (reload.ReloadableProcessSpec, error) {
+	spec := reload.ReloadableProcessSpec{
+		WatchPaths: []string{projectPath},
+	}
+
+	for _, name := range generatedIgnores {
+		spec.IgnorePaths = append(spec.IgnorePaths, filepath.Join(projectPath, name))
+	}
+
+	for _, name := range []string{".gitignore", ".dockerignore"} {
+		patterns, err := ignoreFilePatterns(projectPath, name)
+		if err != nil {
+			return reload.ReloadableProcessSpec{}, err
+		}
+		spec.IgnorePaths = append(spec.IgnorePaths, patterns...)
+	}
+
+	watchPaths, err := projectPaths("BP_LIVE_RELOAD_WATCH_PATHS", projectPath)
+	if err != nil {
+		return reload.ReloadableProcessSpec{}, err
+	}
+
+	for _, path := range watchPaths {
+		if _, err := os.Stat(path); err != nil {
+			return reload.ReloadableProcessSpec{}, fmt.Errorf("failed to parse BP_LIVE_RELOAD_WATCH_PATHS: watch path %s does not exist", path)
+		}
+	}
+
+	if len(watchPaths) > 0 {
+		spec.WatchPaths = watchPaths
+	}
+
+	ignorePaths, err := projectPaths("BP_LIVE_RELOAD_IGNORE_PATHS", projectPath)
+	if err != nil {
+		return reload.ReloadableProcessSpec{}, err
+	}
+	spec.IgnorePaths = compact(append(spec.IgnorePaths, ignorePaths...))
+
+	for _, extension := range splitList(os.Getenv("BP_LIVE_RELOAD_EXTENSIONS")) {
+		extension = strings.TrimPrefix(extension, ".")
+		if !validExtension.MatchString(extension) {
+			return reload.ReloadableProcessSpec{}, fmt.Errorf("failed to parse BP_LIVE_RELOAD_EXTENSIONS: %q is not a valid file extension", extension)
+		}
+		spec.Extensions = append(spec.Extensions, extension)
+	}
+
+	if value := os.Getenv("BP_LIVE_RELOAD_SIGNAL"); value != "" {
+		spec.Signal, err = parseSignal(value)
+		if err != nil {
+			return reload.ReloadableProcessSpec{}, fmt.Errorf("failed to parse BP_LIVE_RELOAD_SIGNAL value %s: %w", value, err)
+		}
+	}
+
+	if value := os.Getenv("BP_LIVE_RELOAD_DEBOUNCE"); value != "" {
+		spec.Debounce, err = time.ParseDuration(value)
+		if err != nil || spec.Debounce <= 0 {
+			return reload.ReloadableProcessSpec{}, fmt.Errorf("failed to parse BP_LIVE_RELOAD_DEBOUNCE value %s: must be a positive duration such as 500ms", value)
+		}
+	}
+
+	switch mode := os.Getenv("BP_LIVE_RELOAD_MODE"); mode {
+	case "", "restart":
+	case "signal":
+		spec.SignalOnly = true
+	default:
+		return reload.ReloadableProcessSpec{}, fmt.Errorf("failed to parse BP_LIVE_RELOAD_MODE value %s: must be one of restart or signal", mode)
+	}
+
+	if reload.SelectedBackend() == reload.BackendNode {
+		return nodeReloadableProcessSpec(spec, projectPath, len(watchPaths) > 0)
+	}
+
+	return spec, nil
+}
+
+// nodeReloadableProcessSpec adapts spec to the node backend, which cannot
+// ignore paths, filter files by extension, debounce or only signal the
+// process. Those settings are rejected, and
+// unless the watch paths were configured, the project is watched through its
+// top-level entries that are not ignored, so that node_modules and the like
+// are left out. Ignores below the top level cannot be honored, and entries
+// created after the build are not watched.
+func nodeReloadableProcessSpec(spec reload.ReloadableProcessSpec, projectPath string, configured bool) (reload.ReloadableProcessSpec, error) {
+	for _, name := range []string{"BP_LIVE_RELOAD_IGNORE_PATHS", "BP_LIVE_RELOAD_EXTENSIONS"} {
+		if os.Getenv(name) != "" {
+			return reload.ReloadableProcessSpec{}, fmt.Errorf("%s is not supported with BP_LIVE_RELOAD_BACKEND=node, which watches every file in the watch paths", name)
+		}
+	}
+
+	if os.Getenv("BP_LIVE_RELOAD_DEBOUNCE") != "" {
+		return reload.ReloadableProcessSpec{}, fmt.Errorf("BP_LIVE_RELOAD_DEBOUNCE is not supported with BP_LIVE_RELOAD_BACKEND=node, which restarts on every change")
+	}
+
+	if spec.SignalOnly {
+		return reload.ReloadableProcessSpec{}, fmt.Errorf("BP_LIVE_RELOAD_MODE=signal is not supported with BP_LIVE_RELOAD_BACKEND=node, which always restarts the process")
+	}
+
+	if !configured {
+		entries, err := os.ReadDir(projectPath)
+		if err != nil {
+			return reload.ReloadableProcessSpec{}, fmt.Errorf("failed to read project path: %w", err)
+		}
+
+		spec.WatchPaths = nil
+		for _, entry := range entries {
+			path := filepath.Join(projectPath, entry.Name())
+			if !isIgnored(path, spec.IgnorePaths) {
+				spec.WatchPaths = append(spec.WatchPaths, path)
+			}
+		}
+	}
+
+	spec.IgnorePaths = nil
+
+	return spec, nil
+}
+
+// isIgnored reports whether path matches one of the ignore patterns, either
+// directly or, for a pattern such as dir/**/name, by its name.
+func isIgnored(path string, ignorePaths []string) bool {
+	for _, pattern := range ignorePaths {
+		if match, _ := filepath.Match(pattern, path); match {
+			return true
+		}
+
+		if dir, name, ok := strings.Cut(pattern, "/**/"); ok && dir == filepath.Dir(path) {
+			if match, _ := filepath.Match(name, filepath.Base(path)); match {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// signals are the names accepted for BP_LIVE_RELOAD_SIGNAL.
+var signals = []string{"SIGHUP", "SIGINT", "SIGQUIT", "SIGTERM", "SIGUSR1", "SIGUSR2"}
+
+// parseSignal normalizes a signal name such as "usr2" to "SIGUSR2".
+func parseSignal(value string) (string, error) {
+	signal := strings.ToUpper(value)
+	if !strings.HasPrefix(signal, "SIG") {
+		signal = "SIG" + signal
+	}
+
+	if !slices.Contains(signals, signal) {
+		return "", fmt.Errorf("must be one of %s", strings.Join(signals, ", "))
+	}
+
+	return signal, nil
+}
+
+// projectPaths resolves the comma-separated paths in the named environment
+// variable relative to projectPath, rejecting paths outside of it.
+func projectPaths(name, projectPath string) ([]string, error) {
+	var paths []string
+	for _, path := range splitList(os.Getenv(name)) {
+		resolved := filepath.Join(projectPath, path)
+		if rel, err := filepath.Rel(projectPath, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("failed to parse %s: %s is outside of the project path %s", name, path, projectPath)
+		}
+		paths = append(paths, resolved)
+	}
+
+	return paths, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+
+	return items
+}
+
+// ignoreFilePatterns translates the patterns in the named ignore file in
+// projectPath into reloader ignore globs. Patterns in a .gitignore that do
+// not contain a slash match at any depth, while other patterns, and all
+// .dockerignore patterns, are anchored to the project path. Negated patterns
+// cannot be expressed as ignores and are skipped.
+func ignoreFilePatterns(projectPath, name string) ([]string, error) {
+	content, err := os.ReadFile(filepath.Join(projectPath, name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to read %s: %w", name, err)
+	}
+
+	var patterns []string
+	for _, line := range strings.Split(string(content), "\n") {
+		pattern := strings.TrimSpace(line)
+		if pattern == "" || strings.HasPrefix(pattern, "#") || strings.HasPrefix(pattern, "!") {
+			continue
+		}
+
+		pattern = strings.TrimSuffix(pattern, "/")
+		anchored := name == ".dockerignore" || strings.Contains(pattern, "/")
+		pattern = strings.TrimPrefix(pattern, "/")
+		if pattern == "" {
+			continue
+		}
+
+		if !anchored && !strings.HasPrefix(pattern, "**") {
+			pattern = filepath.Join("**", pattern)
+		}
+
+		patterns = append(patterns, filepath.Join(projectPath, pattern))
+	}
+
+	return patterns, nil
+}
+
+// compact removes duplicate paths while preserving their order.
+func compact(paths []string) []string {
+	seen := map[string]bool{}
+
+	var unique []string
+	for _, path := range paths {
+		if !seen[path] {
+			seen[path] = true
+			unique = append(unique, path)
+		}
+	}
+
+	return unique
+}
+
+// shouldReinstallDependencies reports whether BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES
+// is enabled.
+func shouldReinstallDependencies() (bool, error) {
+	value, ok := os.LookupEnv("BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES")
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	reinstall, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse BP_LIVE_RELOAD_REINSTALL_DEPENDENCIES value %s: %w", value, err)
+	}
+
+	return reinstall, nil
+}
+
+// manifestSpec watches only the dependency manifests in projectPath.
+func manifestSpec(projectPath string) reload.ReloadableProcessSpec {
+	return reload.ReloadableProcessSpec{
+		WatchPaths: []string{
+			filepath.Join(projectPath, "package.json"),
+			filepath.Join(projectPath, "package-lock.json"),
+		},
+	}
+}
+
+// createReinstallScript writes the ReinstallScript that starts process into
+// projectPath.
+func createReinstallScript(projectPath string, process packit.Process) (string, error) {
+	command := []string{shellQuote(process.Command)}
+	for _, arg := range process.Args {
+		command = append(command, shellQuote(arg))
+	}
+
+	path := filepath.Join(projectPath, "reinstall.sh")
+	err := os.WriteFile(path, []byte(fmt.Sprintf(ReinstallScript, shellQuote(projectPath), strings.Join(command, " "))), 0644)
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+func shellQuote(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", `'\''`) + "'"
+}
@@ -0,0 +1,83 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+const (
+	DefaultDiagnosticsDir    = "/tmp"
+	DefaultDiagnosticsSignal = "SIGUSR2"
+
+	// CPUProfileOption makes the profile process write a CPU profile to the
+	// diagnostic directory when it exits.
+	CPUProfileOption = "--cpu-prof"
+)
+
+// diagnostics holds the settings of the Node diagnostics profile.
+type diagnostics struct {
+	// Dir receives the reports, heap snapshots and CPU profiles.
+	Dir string
+
+	// Signal makes node write a heap snapshot.
+	Signal string
+}
+
+// diagnosticsSettings parses BP_NODE_DIAGNOSTICS, BP_NODE_DIAGNOSTICS_DIR and
+// BP_NODE_DIAGNOSTICS_SIGNAL. It returns nil when diagnostics are disabled.
+func diagnosticsSettings() (*diagnostics, error) {
+	value := os.Getenv("BP_NODE_DIAGNOSTICS")
+	if value == "" {
+		return nil, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse BP_NODE_DIAGNOSTICS value %s: %w", value, err)
+	}
+
+	if !enabled {
+		return nil, nil
+	}
+
+	settings := &diagnostics{
+		Dir:    DefaultDiagnosticsDir,
+		Signal: DefaultDiagnosticsSignal,
+	}
+
+	if dir := os.Getenv("BP_NODE_DIAGNOSTICS_DIR"); dir != "" {
+		if !filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("failed to parse BP_NODE_DIAGNOSTICS_DIR value %s: must be an absolute path", dir)
+		}
+		settings.Dir = filepath.Clean(dir)
+	}
+
+	if value := os.Getenv("BP_NODE_DIAGNOSTICS_SIGNAL"); value != "" {
+		signal, err := parseSignal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse BP_NODE_DIAGNOSTICS_SIGNAL value %s: %w", value, err)
+		}
+
+		if signal == "SIGTERM" || signal == "SIGINT" {
+			return nil, fmt.Errorf("failed to parse BP_NODE_DIAGNOSTICS_SIGNAL value %s: must not be SIGTERM or SIGINT, which stop the app", value)
+		}
+		settings.Signal = signal
+	}
+
+	return settings, nil
+}
+
+// nodeOptions returns the node options that write a report on fatal errors,
+// a heap snapshot on the signal and when the heap nears its limit, all of
+// them to the diagnostic directory.
+func (d diagnostics) nodeOptions() []string {
+	return []string{
+		"--report-on-fatalerror",
+		fmt.Sprintf("--report-directory=%s", d.Dir),
+		fmt.Sprintf("--heapsnapshot-signal=%s", d.Signal),
+		"--heapsnapshot-near-heap-limit=1",
+		fmt.Sprintf("--diagnostic-dir=%s", d.Dir),
+	}
+}
@@ -0,0 +1,137 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// ImportPrefix marks an entry of BP_NODE_PRELOAD, such as
+// import:./loader.mjs, that is loaded with --import rather than --require.
+const ImportPrefix = "import:"
+
+// preloadModule is a module that node loads ahead of the app.
+type preloadModule struct {
+	Specifier string
+	Import    bool
+}
+
+func (m preloadModule) option() string {
+	if m.Import {
+		return fmt.Sprintf("--import %s", m.Specifier)
+	}
+
+	return fmt.Sprintf("--require %s", m.Specifier)
+}
+
+// preloadModules parses the comma-separated modules in BP_NODE_PRELOAD.
+func preloadModules() []preloadModule {
+	var modules []preloadModule
+	for _, item := range splitList(os.Getenv("BP_NODE_PRELOAD")) {
+		module := preloadModule{Specifier: item}
+		if specifier, ok := strings.CutPrefix(item, ImportPrefix); ok {
+			module = preloadModule{Specifier: specifier, Import: true}
+		}
+		modules = append(modules, module)
+	}
+
+	return modules
+}
+
+// startFlags parses the whitespace-separated node flags in
+// BP_NODE_START_FLAGS.
+func startFlags() ([]string, error) {
+	flags := strings.Fields(os.Getenv("BP_NODE_START_FLAGS"))
+	for _, flag := range flags {
+		if !strings.HasPrefix(flag, "-") {
+			return nil, fmt.Errorf("failed to parse BP_NODE_START_FLAGS value %s: %s is not a flag; pass values as --flag=value", os.Getenv("BP_NODE_START_FLAGS"), flag)
+		}
+	}
+
+	return flags, nil
+}
+
+// validatePreloads returns a description of every module that will not
+// resolve when node starts in the directory of target: relative modules must
+// exist there and packages must be installed in one of its node_modules
+// directories, and not only as a devDependency.
+func validatePreloads(modules []preloadModule, target startTarget, development bool) []string {
+	var problems []string
+	for _, module := range modules {
+		specifier := module.Specifier
+
+		switch {
+		case strings.HasPrefix(specifier, "node:"):
+			continue
+
+		case filepath.IsAbs(specifier) || strings.HasPrefix(specifier, "./") || strings.HasPrefix(specifier, "../"):
+			path := specifier
+			if !filepath.IsAbs(path) {
+				path = filepath.Join(target.Path, specifier)
+			}
+
+			if !fileExists(path, path+".js", path+".mjs", path+".cjs", filepath.Join(path, "index.js")) {
+				problems = append(problems, fmt.Sprintf("preloaded module %q does not exist (resolved to %s)", specifier, path))
+			}
+
+		default:
+			pkg := modulePackage(specifier)
+
+			var dirs []string
+			for _, binDir := range target.BinDirs {
+				dirs = append(dirs, filepath.Join(filepath.Dir(binDir), pkg))
+			}
+
+			if !fileExists(dirs...) {
+				problems = append(problems, fmt.Sprintf("preloaded module %q is not installed in %s", specifier, strings.Join(dirs, " or ")))
+				continue
+			}
+
+			if !development {
+				if _, ok := target.Manifest.DevDependencies[pkg]; ok {
+					if _, ok := target.Manifest.Dependencies[pkg]; !ok {
+						problems = append(problems, devDependencyProblem("module", specifier, pkg))
+					}
+				}
+			}
+		}
+	}
+
+	return problems
+}
+
+// fileExists reports whether any of the given paths exists.
+func fileExists(paths ...string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// appendNodeOptions appends options to the NODE_OPTIONS of env, after any
+// that are appended already.
+func appendNodeOptions(env packit.Environment, options ...string) {
+	if existing, ok := env["NODE_OPTIONS.append"]; ok {
+		options = append([]string{existing}, options...)
+	}
+
+	env.Append("NODE_OPTIONS", strings.Join(options, " "), " ")
+}
+
+// processEnv returns the launch environment of the given process type,
+// creating it when the layer has none.
+func processEnv(layer packit.Layer, processType string) packit.Environment {
+	env, ok := layer.ProcessLaunchEnv[processType]
+	if !ok {
+		env = packit.Environment{}
+		layer.ProcessLaunchEnv[processType] = env
+	}
+
+	return env
+}
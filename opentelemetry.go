@@ -0,0 +1,89 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+const (
+	// OpenTelemetryPackage is the dependency that turns on OpenTelemetry
+	// auto-instrumentation.
+	OpenTelemetryPackage = "@opentelemetry/auto-instrumentations-node"
+
+	// OpenTelemetryRegister starts the instrumentation when preloaded.
+	OpenTelemetryRegister = OpenTelemetryPackage + "/register"
+)
+
+// openTelemetry describes the auto-instrumentation of the processes of a
+// target.
+type openTelemetry struct {
+	ServiceName    string
+	ServiceVersion string
+}
+
+// openTelemetryEnabled parses BP_NODE_OPENTELEMETRY, which turns off the
+// auto-instrumentation when false.
+func openTelemetryEnabled() (bool, error) {
+	value := os.Getenv("BP_NODE_OPENTELEMETRY")
+	if value == "" {
+		return true, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse BP_NODE_OPENTELEMETRY value %s: %w", value, err)
+	}
+
+	return enabled, nil
+}
+
+// openTelemetrySettings returns the auto-instrumentation of target, or nil
+// when its package.json does not depend on OpenTelemetryPackage or the
+// register module is already preloaded.
+func openTelemetrySettings(target startTarget, preloads []preloadModule) *openTelemetry {
+	if _, ok := target.Manifest.Dependencies[OpenTelemetryPackage]; !ok {
+		return nil
+	}
+
+	for _, module := range preloads {
+		if module.Specifier == OpenTelemetryRegister {
+			return nil
+		}
+	}
+
+	return &openTelemetry{
+		ServiceName:    target.Manifest.Name,
+		ServiceVersion: target.Manifest.Version,
+	}
+}
+
+// module returns the preloaded module that registers the instrumentation.
+func (o openTelemetry) module() preloadModule {
+	return preloadModule{Specifier: OpenTelemetryRegister}
+}
+
+// description names the service as it is reported.
+func (o openTelemetry) description() string {
+	if o.ServiceVersion == "" {
+		return o.ServiceName
+	}
+
+	return fmt.Sprintf("%s %s", o.ServiceName, o.ServiceVersion)
+}
+
+// configure adds the instrumentation to env, leaving the service name and
+// version to any OTEL_SERVICE_NAME or OTEL_RESOURCE_ATTRIBUTES set at launch.
+func (o openTelemetry) configure(env packit.Environment) {
+	appendNodeOptions(env, o.module().option())
+
+	if o.ServiceName != "" {
+		env.Default("OTEL_SERVICE_NAME", o.ServiceName)
+	}
+
+	if o.ServiceVersion != "" {
+		env.Default("OTEL_RESOURCE_ATTRIBUTES", fmt.Sprintf("service.version=%s", o.ServiceVersion))
+	}
+}
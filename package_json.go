@@ -0,0 +1,89 @@
+package npmstart
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// packageManifest holds the parts of package.json that are not exposed by
+// libnodejs.PackageJSON.
+type packageManifest struct {
+	Name            string            `json:"name"`
+	Version         string            `json:"version"`
+	Scripts         map[string]string `json:"scripts"`
+	Dependencies    map[string]string `json:"dependencies"`
+	DevDependencies map[string]string `json:"devDependencies"`
+	Workspaces      workspaceGlobs    `json:"workspaces"`
+}
+
+// workspaceGlobs holds the "workspaces" field of package.json, which is
+// either a list of globs or, in the form used by yarn, an object with the
+// globs in its "packages" field.
+type workspaceGlobs []string
+
+func (w *workspaceGlobs) UnmarshalJSON(data []byte) error {
+	var globs []string
+	if err := json.Unmarshal(data, &globs); err == nil {
+		*w = globs
+		return nil
+	}
+
+	var object struct {
+		Packages []string `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &object); err != nil {
+		return fmt.Errorf("workspaces must be a list of globs or an object with a packages list")
+	}
+	*w = object.Packages
+
+	return nil
+}
+
+// startScriptName returns the name of the npm script that is used as the
+// start command, honoring BP_NPM_START_SCRIPT.
+func startScriptName() string {
+	if name, ok := os.LookupEnv("BP_NPM_START_SCRIPT"); ok && name != "" {
+		return name
+	}
+
+	return "start"
+}
+
+func parsePackageManifest(projectPath string) (packageManifest, error) {
+	content, err := os.ReadFile(filepath.Join(projectPath, "package.json"))
+	if err != nil {
+		return packageManifest{}, err
+	}
+
+	var manifest packageManifest
+	err = json.Unmarshal(content, &manifest)
+	if err != nil {
+		var syntaxErr *json.SyntaxError
+		if errors.As(err, &syntaxErr) {
+			line, column := lineAndColumn(content, syntaxErr.Offset)
+			return packageManifest{}, fmt.Errorf("%w (line %d, column %d)", err, line, column)
+		}
+
+		return packageManifest{}, err
+	}
+
+	return manifest, nil
+}
+
+// lineAndColumn converts a byte offset reported by encoding/json into a
+// 1-based line and column. The offset points just past the offending byte.
+func lineAndColumn(content []byte, offset int64) (int, int) {
+	if offset > int64(len(content)) {
+		offset = int64(len(content))
+	}
+
+	preceding := content[:max(offset-1, 0)]
+	line := bytes.Count(preceding, []byte("\n")) + 1
+	column := len(preceding) - bytes.LastIndexByte(preceding, '\n')
+
+	return line, column
+}
@@ -0,0 +1,58 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+const (
+	PrestartInline  = "inline"
+	PrestartProcess = "process"
+	PrestartSkip    = "skip"
+)
+
+// prestartMode parses BP_NPM_START_PRESTART_MODE, which selects whether the
+// prestart script runs before the start script (inline), as a process type of
+// its own (process) or not at all (skip).
+func prestartMode() (string, error) {
+	switch mode := os.Getenv("BP_NPM_START_PRESTART_MODE"); mode {
+	case "":
+		return PrestartInline, nil
+	case PrestartInline, PrestartProcess, PrestartSkip:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("failed to parse BP_NPM_START_PRESTART_MODE value %s: must be one of %s, %s or %s", mode, PrestartInline, PrestartProcess, PrestartSkip)
+	}
+}
+
+// prestartProcess validates the prestart script of target and returns a
+// process that runs it once, for a Kubernetes Job or init container to run
+// ahead of the start process.
+func prestartProcess(logger scribe.Emitter, target startTarget, validation string, development bool, workingDir, layerPath string) (packit.Process, error) {
+	script := target.Package.Scripts.PreStart
+
+	problems, warnings := validateScript(script, target.Path, target.BinDirs)
+	if !development {
+		problems = append(problems, checkDevDependencies([]string{script}, target.Path, target.Manifest)...)
+	}
+
+	err := reportProblems(logger, validation, problems, warnings)
+	if err != nil {
+		return packit.Process{}, err
+	}
+
+	command, err := lifecycleScript(target, "prestart", "", script, "", workingDir, layerPath)
+	if err != nil {
+		return packit.Process{}, err
+	}
+
+	return packit.Process{
+		Type:    variantType(target.Type, "prestart"),
+		Command: command[0],
+		Args:    command[1:],
+		Direct:  true,
+	}, nil
+}
@@ -0,0 +1,34 @@
+package npmstart
+
+import (
+	"os"
+)
+
+const (
+	EnvironmentProduction  = "production"
+	EnvironmentDevelopment = "development"
+
+	// DebugInspectOption is added to NODE_OPTIONS for the debug process.
+	DebugInspectOption = "--inspect=0.0.0.0:9229"
+)
+
+// isDevelopment reports whether BP_ENVIRONMENT selects the development
+// profile. The profile requires node_modules for build as well as launch, so
+// that dev dependencies are installed, enables live reload, starts the "dev"
+// script when there is one, sets NODE_ENV=development and adds a debug
+// process. Since BP_ENVIRONMENT may be set by the app or other buildpacks, any
+// other value, such as staging, means production.
+func isDevelopment() bool {
+	return os.Getenv("BP_ENVIRONMENT") == EnvironmentDevelopment
+}
+
+// developmentStartScript returns the "dev" script that the development
+// profile starts, unless BP_NPM_START_SCRIPT explicitly selects a script.
+func developmentStartScript(manifest packageManifest) (string, bool) {
+	if _, ok := os.LookupEnv("BP_NPM_START_SCRIPT"); ok {
+		return "", false
+	}
+
+	script, ok := manifest.Scripts["dev"]
+	return script, ok && script != ""
+}
@@ -0,0 +1,101 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/libnodejs"
+)
+
+// listedProjectPaths returns the project paths given by the comma-separated
+// BP_NPM_START_PROJECT_PATHS, resolved relative to workingDir. It returns
+// nothing when the variable is not set. BP_NODE_PROJECT_PATH is left to
+// libnodejs.FindProjectPath, since other buildpacks read it as a single path,
+// but must name one of the listed paths when both are set.
+func listedProjectPaths(workingDir string) ([]string, error) {
+	var paths []string
+	for _, path := range splitList(os.Getenv("BP_NPM_START_PROJECT_PATHS")) {
+		resolved := filepath.Join(workingDir, path)
+		if rel, err := filepath.Rel(workingDir, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+			return nil, fmt.Errorf("failed to parse BP_NPM_START_PROJECT_PATHS: %s is outside of the working directory %s", path, workingDir)
+		}
+
+		if _, err := os.Stat(resolved); err != nil {
+			return nil, fmt.Errorf("failed to parse BP_NPM_START_PROJECT_PATHS: project path %s does not exist", resolved)
+		}
+
+		paths = append(paths, resolved)
+	}
+
+	if value := os.Getenv("BP_NODE_PROJECT_PATH"); value != "" && len(paths) > 0 {
+		if !slices.Contains(paths, filepath.Join(workingDir, value)) {
+			return nil, fmt.Errorf("failed to parse BP_NPM_START_PROJECT_PATHS: BP_NODE_PROJECT_PATH %s is not one of the listed project paths", value)
+		}
+	}
+
+	return compact(paths), nil
+}
+
+// projectPathTargets returns a start target, named after the path, for every
+// listed project path whose package.json has a start script. Their
+// StartupScripts are written to scriptDir. Like node resolves modules, the
+// scripts fall back to the executables installed in workingDir, where a single
+// install may provide the dependencies of every path. The paths without a
+// start script are returned separately.
+func projectPathTargets(workingDir string, paths []string, scriptDir string) ([]startTarget, []string, error) {
+	var (
+		targets []startTarget
+		skipped []string
+	)
+	for _, path := range paths {
+		pkg, err := libnodejs.ParsePackageJSON(path)
+		if err != nil {
+			if os.IsNotExist(err) {
+				skipped = append(skipped, path)
+				continue
+			}
+			return nil, nil, fmt.Errorf("failed to parse package.json in project path %s: %w", path, err)
+		}
+
+		if !pkg.HasStartScript() {
+			skipped = append(skipped, path)
+			continue
+		}
+
+		manifest, err := parsePackageManifest(path)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse package.json in project path %s: %w", path, err)
+		}
+
+		rel, err := filepath.Rel(workingDir, path)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		processType := processTypeName(rel)
+		if processType == "" {
+			processType = "web"
+		}
+
+		for _, target := range targets {
+			if target.Type == processType {
+				return nil, nil, fmt.Errorf("project paths %s and %s both map to process type %q", target.Path, path, processType)
+			}
+		}
+
+		targets = append(targets, startTarget{
+			Type:       processType,
+			Path:       path,
+			Package:    pkg,
+			Manifest:   manifest,
+			ScriptName: startScriptName(),
+			BinDirs:    compact([]string{filepath.Join(path, "node_modules", ".bin"), filepath.Join(workingDir, "node_modules", ".bin")}),
+			Script:     filepath.Join(scriptDir, fmt.Sprintf("%s.sh", processType)),
+		})
+	}
+
+	return targets, skipped, nil
+}
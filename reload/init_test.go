@@ -0,0 +1,16 @@
+package reload_test
+
+import (
+	"testing"
+
+	"github.com/sclevine/spec"
+	"github.com/sclevine/spec/report"
+)
+
+func TestUnitReload(t *testing.T) {
+	suite := spec.New("reload", spec.Report(report.Terminal{}), spec.Sequential())
+	suite("Node", testNode)
+	suite("Reload", testReload)
+	suite("Watchexec", testWatchexec)
+	suite.Run(t)
+}
@@ -0,0 +1,106 @@
+package reload
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// NodeSupervisorFile is the file in the layer that WriteNodeSupervisor writes
+// the supervisor to.
+const NodeSupervisorFile = "reload-supervisor.mjs"
+
+// nodeWatchCommand runs node --watch without NODE_OPTIONS, so that modules
+// preloaded for the app are not loaded into the watcher and the supervisor.
+// The options are kept in NPM_START_NODE_OPTIONS for the supervisor to
+// restore.
+const nodeWatchCommand = `export NPM_START_NODE_OPTIONS="${NODE_OPTIONS-}"; unset NODE_OPTIONS; exec node "$@"`
+
+// nodeSupervisor is imported into the watched node process. It runs the
+// original command as a child, with the NODE_OPTIONS put aside by
+// nodeWatchCommand, and ties the child's lifetime to its own, so that the
+// child is stopped whenever node --watch restarts the process. The first
+// argument after the entrypoint is the signal used to stop the child when
+// node --watch restarts the process.
+const nodeSupervisor = `import { spawn } from "node:child_process";
+const stopSignal = process.argv[2];
+const args = process.argv.slice(process.argv.indexOf("--") + 1);
+const env = { ...process.env };
+if (env.NPM_START_NODE_OPTIONS) {
+  env.NODE_OPTIONS = env.NPM_START_NODE_OPTIONS;
+}
+delete env.NPM_START_NODE_OPTIONS;
+const child = spawn(args[0], args.slice(1), { stdio: "inherit", env });
+process.on("SIGTERM", () => child.kill(stopSignal));
+for (const signal of ["SIGINT", "SIGHUP"]) {
+  process.on(signal, () => child.kill(signal));
+}
+child.on("exit", (code, signal) => process.exit(code ?? 1));
+`
+
+// WriteNodeSupervisor writes the supervisor that the NodeReloader imports
+// into the given launch layer.
+func WriteNodeSupervisor(layerPath string) error {
+	return os.WriteFile(filepath.Join(layerPath, NodeSupervisorFile), []byte(nodeSupervisor), 0644)
+}
+
+// NodeReloader restarts the process using the --watch mode built into node,
+// so that no dependency beyond node is needed at launch. Node always restarts
+// the process and does not support ignoring paths, filtering by extension or
+// debouncing, so only the watch paths and the signal used to stop the process
+// are taken from the spec. The reloadable process runs node through sh to
+// clear NODE_OPTIONS, and the supervisor must have been written to the
+// LayerPath of the spec with WriteNodeSupervisor.
+type NodeReloader struct{}
+
+func NewNodeReloader() NodeReloader {
+	return NodeReloader{}
+}
+
+func (NodeReloader) ShouldEnableLiveReload() (bool, error) {
+	return shouldEnableLiveReload()
+}
+
+func (NodeReloader) LaunchRequirements() []string {
+	return nil
+}
+
+func (NodeReloader) TransformReloadableProcesses(originalProcess packit.Process, spec ReloadableProcessSpec) (nonReloadable packit.Process, reloadable packit.Process) {
+	nonReloadable = originalProcess
+	nonReloadable.Default = false
+
+	reloadable = originalProcess
+	reloadable.Type = fmt.Sprintf("reload-%s", originalProcess.Type)
+	reloadable.Command = "sh"
+	reloadable.Args = append([]string{"-c", nodeWatchCommand, "node"}, nodeArgs(originalProcess, spec)...)
+
+	return nonReloadable, reloadable
+}
+
+func nodeArgs(originalProcess packit.Process, spec ReloadableProcessSpec) []string {
+	stopSignal := spec.Signal
+	if stopSignal == "" {
+		stopSignal = "SIGTERM"
+	}
+
+	args := []string{"--watch"}
+
+	for _, watchPath := range spec.WatchPaths {
+		args = append(args, "--watch-path", watchPath)
+	}
+
+	args = append(args,
+		"--watch-preserve-output",
+		"--import", filepath.Join(spec.LayerPath, NodeSupervisorFile),
+		// node --watch requires an entrypoint; the supervisor does all the
+		// work from the import above.
+		"/dev/null",
+		stopSignal,
+		"--",
+		originalProcess.Command,
+	)
+
+	return append(args, originalProcess.Args...)
+}
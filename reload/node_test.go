@@ -0,0 +1,120 @@
+package reload_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/npm-start/reload"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testNode(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		reloader reload.NodeReloader
+	)
+
+	it.Before(func() {
+		reloader = reload.NewNodeReloader()
+	})
+
+	it("is a reload.Reloader", func() {
+		var _ reload.Reloader = reload.NewNodeReloader()
+	})
+
+	it("has no launch requirements", func() {
+		Expect(reloader.LaunchRequirements()).To(BeEmpty())
+	})
+
+	context("ShouldEnableLiveReload", func() {
+		it("is enabled by BP_LIVE_RELOAD_ENABLED", func() {
+			t.Setenv("BP_LIVE_RELOAD_ENABLED", "true")
+
+			enabled, err := reloader.ShouldEnableLiveReload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(enabled).To(BeTrue())
+		})
+
+		it("returns an error when BP_LIVE_RELOAD_ENABLED is malformed", func() {
+			t.Setenv("BP_LIVE_RELOAD_ENABLED", "sometimes")
+
+			_, err := reloader.ShouldEnableLiveReload()
+			Expect(err).To(MatchError(ContainSubstring("failed to parse BP_LIVE_RELOAD_ENABLED value sometimes")))
+		})
+	})
+
+	context("WriteNodeSupervisor", func() {
+		it("writes the supervisor into the layer", func() {
+			layerPath := t.TempDir()
+
+			Expect(reload.WriteNodeSupervisor(layerPath)).To(Succeed())
+
+			content, err := os.ReadFile(filepath.Join(layerPath, reload.NodeSupervisorFile))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(ContainSubstring(`env.NODE_OPTIONS = env.NPM_START_NODE_OPTIONS;`))
+			Expect(string(content)).To(ContainSubstring(`spawn(args[0], args.slice(1), { stdio: "inherit", env })`))
+		})
+
+		context("when the layer does not exist", func() {
+			it("returns an error", func() {
+				Expect(reload.WriteNodeSupervisor(filepath.Join(t.TempDir(), "missing"))).To(MatchError(ContainSubstring("no such file or directory")))
+			})
+		})
+	})
+
+	context("TransformReloadableProcesses", func() {
+		it("runs the process under node --watch without NODE_OPTIONS", func() {
+			nonReloadable, reloadable := reloader.TransformReloadableProcesses(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Args:    []string{"/workspace/start.sh"},
+				Default: true,
+				Direct:  true,
+			}, reload.ReloadableProcessSpec{
+				WatchPaths: []string{"/workspace/src", "/workspace/views"},
+				LayerPath:  "/layers/npm-start",
+			})
+
+			Expect(nonReloadable).To(Equal(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Args:    []string{"/workspace/start.sh"},
+				Direct:  true,
+			}))
+
+			Expect(reloadable.Type).To(Equal("reload-web"))
+			Expect(reloadable.Command).To(Equal("sh"))
+			Expect(reloadable.Default).To(BeTrue())
+			Expect(reloadable.Direct).To(BeTrue())
+
+			Expect(reloadable.Args).To(Equal([]string{
+				"-c", `export NPM_START_NODE_OPTIONS="${NODE_OPTIONS-}"; unset NODE_OPTIONS; exec node "$@"`, "node",
+				"--watch",
+				"--watch-path", "/workspace/src",
+				"--watch-path", "/workspace/views",
+				"--watch-preserve-output",
+				"--import", "/layers/npm-start/reload-supervisor.mjs",
+				"/dev/null", "SIGTERM",
+				"--", "sh", "/workspace/start.sh",
+			}))
+		})
+
+		it("stops the process with the configured signal", func() {
+			_, reloadable := reloader.TransformReloadableProcesses(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Args:    []string{"/workspace/start.sh"},
+			}, reload.ReloadableProcessSpec{
+				WatchPaths: []string{"/workspace"},
+				Signal:     "SIGUSR2",
+			})
+
+			Expect(reloadable.Args[len(reloadable.Args)-5:]).To(Equal([]string{"/dev/null", "SIGUSR2", "--", "sh", "/workspace/start.sh"}))
+		})
+	})
+}
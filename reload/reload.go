@@ -0,0 +1,103 @@
+// Package reload defines how the buildpack wraps its start process so that it
+// restarts when files change. It replaces libreload-packit, which this
+// buildpack used before: its process spec could not express ignore paths,
+// extensions, signals or debouncing, and it only supported watchexec. The
+// Reloader interface and the watchexec reloader follow the ones there.
+package reload
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+const (
+	BackendWatchexec = "watchexec"
+	BackendNode      = "node"
+
+	// LiveReloadEnabledEnvVar enables live reload, as it did with
+	// libreload-packit.
+	LiveReloadEnabledEnvVar = "BP_LIVE_RELOAD_ENABLED"
+)
+
+// ReloadableProcessSpec describes what a Reloader should watch and how it
+// should run the wrapped process.
+type ReloadableProcessSpec struct {
+	// WatchPaths are the files and directories to watch for changes.
+	WatchPaths []string
+
+	// IgnorePaths are the files and directories whose changes are ignored.
+	IgnorePaths []string
+
+	// Extensions, when set, restricts the watched files to those with one of
+	// the given file extensions (without the leading dot).
+	Extensions []string
+
+	// Signal is the signal sent to the process when files change. When the
+	// process is restarted, it is the signal used to stop it. Defaults to
+	// SIGTERM when restarting and SIGHUP in signal-only mode.
+	Signal string
+
+	// Debounce is how long to wait for further changes before acting on a
+	// change. Defaults to the reloader's own default.
+	Debounce time.Duration
+
+	// SignalOnly sends Signal to the running process on change instead of
+	// restarting it, for processes that reload themselves.
+	SignalOnly bool
+
+	// Shell is the shell used to run the process. Defaults to none.
+	Shell string
+
+	// VerbosityLevel is the number of verbosity flags given to the reloader.
+	VerbosityLevel int
+
+	// LayerPath is the launch layer holding the files the reloader runs at
+	// launch, such as the supervisor written by WriteNodeSupervisor.
+	LayerPath string
+}
+
+type Reloader interface {
+	ShouldEnableLiveReload() (bool, error)
+	TransformReloadableProcesses(originalProcess packit.Process, spec ReloadableProcessSpec) (nonReloadable packit.Process, reloadable packit.Process)
+
+	// LaunchRequirements names the dependencies the reloadable process needs
+	// at launch in addition to those of the original process.
+	LaunchRequirements() []string
+}
+
+// SelectedBackend returns the backend selected with BP_LIVE_RELOAD_BACKEND,
+// defaulting to watchexec.
+func SelectedBackend() string {
+	if backend := os.Getenv("BP_LIVE_RELOAD_BACKEND"); backend != "" {
+		return backend
+	}
+
+	return BackendWatchexec
+}
+
+// NewReloader returns the Reloader for the SelectedBackend.
+func NewReloader() (Reloader, error) {
+	switch backend := SelectedBackend(); backend {
+	case BackendWatchexec:
+		return NewWatchexecReloader(), nil
+	case BackendNode:
+		return NewNodeReloader(), nil
+	default:
+		return nil, fmt.Errorf("failed to parse BP_LIVE_RELOAD_BACKEND value %s: must be one of %s or %s", backend, BackendWatchexec, BackendNode)
+	}
+}
+
+func shouldEnableLiveReload() (bool, error) {
+	if reload, found := os.LookupEnv(LiveReloadEnabledEnvVar); found {
+		if shouldEnableReload, err := strconv.ParseBool(reload); err != nil {
+			return false, fmt.Errorf("failed to parse %s value %s: %w", LiveReloadEnabledEnvVar, reload, err)
+		} else if shouldEnableReload {
+			return true, nil
+		}
+	}
+	return false, nil
+}
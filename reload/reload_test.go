@@ -0,0 +1,37 @@
+package reload_test
+
+import (
+	"testing"
+
+	"github.com/paketo-buildpacks/npm-start/reload"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testReload(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("NewReloader", func() {
+		it("defaults to watchexec", func() {
+			reloader, err := reload.NewReloader()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reloader).To(Equal(reload.NewWatchexecReloader()))
+		})
+
+		it("selects the node backend", func() {
+			t.Setenv("BP_LIVE_RELOAD_BACKEND", "node")
+
+			reloader, err := reload.NewReloader()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(reloader).To(Equal(reload.NewNodeReloader()))
+		})
+
+		it("returns an error for an unknown backend", func() {
+			t.Setenv("BP_LIVE_RELOAD_BACKEND", "nodemon")
+
+			_, err := reload.NewReloader()
+			Expect(err).To(MatchError("failed to parse BP_LIVE_RELOAD_BACKEND value nodemon: must be one of watchexec or node"))
+		})
+	})
+}
@@ -0,0 +1,82 @@
+package reload
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// WatchexecReloader restarts the process using watchexec, which must be
+// provided at launch by the watchexec buildpack.
+type WatchexecReloader struct{}
+
+func NewWatchexecReloader() WatchexecReloader {
+	return WatchexecReloader{}
+}
+
+func (WatchexecReloader) ShouldEnableLiveReload() (bool, error) {
+	return shouldEnableLiveReload()
+}
+
+func (WatchexecReloader) LaunchRequirements() []string {
+	return []string{"watchexec"}
+}
+
+func (WatchexecReloader) TransformReloadableProcesses(originalProcess packit.Process, spec ReloadableProcessSpec) (nonReloadable packit.Process, reloadable packit.Process) {
+	nonReloadable = originalProcess
+	nonReloadable.Default = false
+
+	reloadable = originalProcess
+	reloadable.Type = fmt.Sprintf("reload-%s", originalProcess.Type)
+	reloadable.Command = "watchexec"
+	reloadable.Args = watchexecArgs(originalProcess, spec)
+
+	return nonReloadable, reloadable
+}
+
+func watchexecArgs(originalProcess packit.Process, spec ReloadableProcessSpec) []string {
+	var args []string
+	if spec.SignalOnly {
+		signal := spec.Signal
+		if signal == "" {
+			signal = "SIGHUP"
+		}
+		args = append(args, "--on-busy-update", "signal", "--signal", signal)
+	} else {
+		args = append(args, "--restart")
+		if spec.Signal != "" {
+			args = append(args, "--stop-signal", spec.Signal)
+		}
+	}
+
+	if spec.Debounce > 0 {
+		args = append(args, "--debounce", strconv.FormatInt(spec.Debounce.Milliseconds(), 10))
+	}
+
+	for _, watchPath := range spec.WatchPaths {
+		args = append(args, "--watch", watchPath)
+	}
+
+	for _, ignorePath := range spec.IgnorePaths {
+		args = append(args, "--ignore", ignorePath)
+	}
+
+	if len(spec.Extensions) > 0 {
+		args = append(args, "--exts", strings.Join(spec.Extensions, ","))
+	}
+
+	shell := "none"
+	if spec.Shell != "" {
+		shell = spec.Shell
+	}
+	args = append(args, "--shell", shell)
+
+	if spec.VerbosityLevel > 0 {
+		args = append(args, "-"+strings.Repeat("v", spec.VerbosityLevel))
+	}
+
+	args = append(args, "--", originalProcess.Command)
+	return append(args, originalProcess.Args...)
+}
@@ -0,0 +1,156 @@
+package reload_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/reload"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+)
+
+func testWatchexec(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		reloader reload.WatchexecReloader
+	)
+
+	it.Before(func() {
+		reloader = reload.NewWatchexecReloader()
+	})
+
+	it("is a reload.Reloader", func() {
+		var _ reload.Reloader = reload.NewWatchexecReloader()
+	})
+
+	it("requires watchexec at launch", func() {
+		Expect(reloader.LaunchRequirements()).To(Equal([]string{"watchexec"}))
+	})
+
+	context("ShouldEnableLiveReload", func() {
+		it("is enabled by BP_LIVE_RELOAD_ENABLED", func() {
+			t.Setenv("BP_LIVE_RELOAD_ENABLED", "true")
+
+			enabled, err := reloader.ShouldEnableLiveReload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(enabled).To(BeTrue())
+		})
+
+		it("is disabled by default", func() {
+			enabled, err := reloader.ShouldEnableLiveReload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(enabled).To(BeFalse())
+		})
+	})
+
+	context("TransformReloadableProcesses", func() {
+		var originalProcess packit.Process
+
+		it.Before(func() {
+			originalProcess = packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Args:    []string{"/workspace/start.sh"},
+				Default: true,
+				Direct:  true,
+			}
+		})
+
+		it("wraps the process with watchexec", func() {
+			nonReloadable, reloadable := reloader.TransformReloadableProcesses(originalProcess, reload.ReloadableProcessSpec{
+				WatchPaths:     []string{"/workspace"},
+				IgnorePaths:    []string{"/workspace/node_modules", "/workspace/logs"},
+				Extensions:     []string{"js", "ts"},
+				VerbosityLevel: 2,
+			})
+
+			Expect(nonReloadable).To(Equal(packit.Process{
+				Type:    "web",
+				Command: "sh",
+				Args:    []string{"/workspace/start.sh"},
+				Direct:  true,
+			}))
+
+			Expect(reloadable).To(Equal(packit.Process{
+				Type:    "reload-web",
+				Command: "watchexec",
+				Args: []string{
+					"--restart",
+					"--watch", "/workspace",
+					"--ignore", "/workspace/node_modules",
+					"--ignore", "/workspace/logs",
+					"--exts", "js,ts",
+					"--shell", "none",
+					"-vv",
+					"--",
+					"sh", "/workspace/start.sh",
+				},
+				Default: true,
+				Direct:  true,
+			}))
+		})
+
+		it("stops the process with the configured signal after the debounce interval", func() {
+			_, reloadable := reloader.TransformReloadableProcesses(originalProcess, reload.ReloadableProcessSpec{
+				WatchPaths: []string{"/workspace"},
+				Signal:     "SIGINT",
+				Debounce:   1500 * time.Millisecond,
+			})
+
+			Expect(reloadable.Args).To(Equal([]string{
+				"--restart",
+				"--stop-signal", "SIGINT",
+				"--debounce", "1500",
+				"--watch", "/workspace",
+				"--shell", "none",
+				"--",
+				"sh", "/workspace/start.sh",
+			}))
+		})
+
+		context("when the spec is signal-only", func() {
+			it("signals the running process instead of restarting it", func() {
+				_, reloadable := reloader.TransformReloadableProcesses(originalProcess, reload.ReloadableProcessSpec{
+					WatchPaths: []string{"/workspace"},
+					Signal:     "SIGUSR2",
+					SignalOnly: true,
+				})
+
+				Expect(reloadable.Args).To(Equal([]string{
+					"--on-busy-update", "signal",
+					"--signal", "SIGUSR2",
+					"--watch", "/workspace",
+					"--shell", "none",
+					"--",
+					"sh", "/workspace/start.sh",
+				}))
+			})
+
+			it("defaults to SIGHUP", func() {
+				_, reloadable := reloader.TransformReloadableProcesses(originalProcess, reload.ReloadableProcessSpec{
+					SignalOnly: true,
+				})
+
+				Expect(reloadable.Args[:4]).To(Equal([]string{"--on-busy-update", "signal", "--signal", "SIGHUP"}))
+			})
+		})
+
+		it("omits the extension filter when there are no extensions", func() {
+			_, reloadable := reloader.TransformReloadableProcesses(originalProcess, reload.ReloadableProcessSpec{
+				WatchPaths: []string{"/workspace"},
+				Shell:      "bash",
+			})
+
+			Expect(reloadable.Args).To(Equal([]string{
+				"--restart",
+				"--watch", "/workspace",
+				"--shell", "bash",
+				"--",
+				"sh", "/workspace/start.sh",
+			}))
+		})
+	})
+}
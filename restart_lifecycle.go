@@ -0,0 +1,79 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+)
+
+// restartSignal parses BP_NPM_START_RESTART_SIGNAL and reports whether it was
+// set, which enables the restart lifecycle.
+func restartSignal() (string, bool, error) {
+	value := os.Getenv("BP_NPM_START_RESTART_SIGNAL")
+	if value == "" {
+		return "", false, nil
+	}
+
+	signal, err := parseSignal(value)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse BP_NPM_START_RESTART_SIGNAL value %s: %w", value, err)
+	}
+
+	// The launcher restarts on the signal instead of stopping, so the signals
+	// that stop the container cannot be used.
+	if signal == "SIGTERM" || signal == "SIGINT" {
+		return "", false, fmt.Errorf("failed to parse BP_NPM_START_RESTART_SIGNAL value %s: must not be SIGTERM or SIGINT, which stop the app", value)
+	}
+
+	return signal, true, nil
+}
+
+// reloadHook writes the scripts of the npm restart lifecycle of target to
+// layerPath and returns the ReloadHook that runs them on signal. Like npm,
+// it runs the restart script when there is one, and otherwise stops and
+// starts the process between the prerestart and postrestart scripts.
+func reloadHook(target startTarget, signal, workingDir, layerPath string) (*launcher.ReloadHook, error) {
+	scripts := target.Manifest.Scripts
+	hook := &launcher.ReloadHook{Signal: signal}
+
+	var err error
+	if scripts["restart"] != "" {
+		hook.Command, err = lifecycleScript(target, "restart", scripts["prerestart"], scripts["restart"], scripts["postrestart"], workingDir, layerPath)
+		return hook, err
+	}
+
+	if scripts["prerestart"] != "" {
+		hook.Pre, err = lifecycleScript(target, "prerestart", "", scripts["prerestart"], "", workingDir, layerPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if scripts["postrestart"] != "" {
+		hook.Post, err = lifecycleScript(target, "postrestart", "", scripts["postrestart"], "", workingDir, layerPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return hook, nil
+}
+
+// lifecycleScript writes a script that runs pre, script and post in the
+// directory of target to layerPath and returns the command that runs it.
+func lifecycleScript(target startTarget, name, pre, script, post, workingDir, layerPath string) ([]string, error) {
+	lifecycle := target
+	lifecycle.Package.Scripts.PreStart = pre
+	lifecycle.Package.Scripts.Start = script
+	lifecycle.Package.Scripts.PostStart = post
+
+	path := filepath.Join(layerPath, fmt.Sprintf("%s-%s.sh", target.Type, name))
+	err := os.WriteFile(path, []byte(startCommand(lifecycle, workingDir)+"\n"), 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	return []string{"sh", path}, nil
+}
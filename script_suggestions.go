@@ -0,0 +1,74 @@
+package npmstart
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// describeMissingStartScript explains why no start script could be found in
+// the package.json located in projectPath, listing the scripts that do exist
+// and suggesting the closest match for the configured script name.
+func describeMissingStartScript(projectPath string, manifest packageManifest) string {
+	name := startScriptName()
+
+	var available []string
+	for script := range manifest.Scripts {
+		available = append(available, script)
+	}
+	slices.Sort(available)
+
+	message := fmt.Sprintf("%s: looked for script %q in %s", NoStartScriptError, name, filepath.Join(projectPath, "package.json"))
+	if len(available) == 0 {
+		return message + "; package.json defines no scripts"
+	}
+
+	message = fmt.Sprintf("%s; available scripts: %s", message, strings.Join(available, ", "))
+	if suggestion, ok := closestMatch(name, available); ok {
+		message = fmt.Sprintf("%s; did you mean %q?", message, suggestion)
+	}
+
+	return message
+}
+
+// closestMatch returns the candidate with the smallest edit distance to name,
+// provided that distance is small enough for the candidate to be a plausible
+// typo.
+func closestMatch(name string, candidates []string) (string, bool) {
+	threshold := max(2, len(name)/3)
+
+	var (
+		match    string
+		distance = threshold + 1
+	)
+	for _, candidate := range candidates {
+		if d := editDistance(strings.ToLower(name), strings.ToLower(candidate)); d < distance {
+			match, distance = candidate, d
+		}
+	}
+
+	return match, match != ""
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+
+	return previous[len(b)]
+}
@@ -0,0 +1,221 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	libnodejs "github.com/paketo-buildpacks/libnodejs"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+// startTarget is an app, or a package within one, whose npm start lifecycle
+// becomes a launch process.
+type startTarget struct {
+	// Type is the process type of the launch process.
+	Type string
+
+	// Path is the directory the start lifecycle runs in.
+	Path string
+
+	Package  libnodejs.PackageJSON
+	Manifest packageManifest
+
+	// ScriptName is the npm script, such as start or dev, that
+	// Package.Scripts.Start holds.
+	ScriptName string
+
+	// BinDirs are the node_modules/.bin directories that provide the
+	// executables used by the scripts, in lookup order.
+	BinDirs []string
+
+	// Script is the path that the generated StartupScript is written to.
+	Script string
+
+	Default bool
+
+	// RelaySignal is a signal, such as SIGUSR2, that the StartupScript relays
+	// to the app instead of exiting on it.
+	RelaySignal string
+
+	// Supervised is set when the StartupScript runs under the launcher, which
+	// relays signals to its whole process group.
+	Supervised bool
+}
+
+// variantType returns the process type of a variant, such as "no-reload", of
+// the process of the given type. The variants of the web process keep their
+// historical names.
+func variantType(processType, variant string) string {
+	if processType == "web" {
+		return variant
+	}
+
+	return fmt.Sprintf("%s-%s", processType, variant)
+}
+
+var invalidProcessTypeCharacters = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// processTypeName sanitizes a package name such as "@acme/api" into a valid
+// process type such as "acme-api".
+func processTypeName(name string) string {
+	name = invalidProcessTypeCharacters.ReplaceAllString(name, "-")
+	return strings.Trim(name, "-.")
+}
+
+// startProcess validates the start lifecycle of target and returns the
+// process that runs it, either directly under tini or through a generated
+// StartupScript.
+func startProcess(logger scribe.Emitter, target startTarget, tini bool, validation string, development bool, workingDir string) (packit.Process, error) {
+	pkg := target.Package
+
+	if tini {
+		startParts := strings.Fields(pkg.Scripts.Start)
+		if len(startParts) == 0 {
+			return packit.Process{}, fmt.Errorf("failed to parse start script %q", pkg.Scripts.Start)
+		}
+
+		logger.Process("Using tini for process launching")
+		if pkg.Scripts.PreStart != "" || pkg.Scripts.PostStart != "" {
+			logger.Subprocess("Warning: skipping prestart and/or poststart scripts because BP_LAUNCH_WITH_TINI is enabled")
+		}
+
+		problems, warnings := validateCommand(startParts, target.Path, target.BinDirs)
+		if !development {
+			problems = append(problems, checkDevDependencies([]string{pkg.Scripts.Start}, target.Path, target.Manifest)...)
+		}
+
+		err := reportProblems(logger, validation, problems, warnings)
+		if err != nil {
+			return packit.Process{}, err
+		}
+
+		return packit.Process{
+			Type:    target.Type,
+			Command: Tini,
+			Args:    append([]string{"-g", "--"}, startParts...),
+			Default: target.Default,
+			Direct:  true,
+		}, nil
+	}
+
+	scripts := []string{pkg.Scripts.PreStart, pkg.Scripts.Start, pkg.Scripts.PostStart}
+
+	var problems, warnings []string
+	for _, script := range scripts {
+		p, w := validateScript(script, target.Path, target.BinDirs)
+		problems = append(problems, p...)
+		warnings = append(warnings, w...)
+	}
+	if !development {
+		problems = append(problems, checkDevDependencies(scripts, target.Path, target.Manifest)...)
+	}
+
+	err := reportProblems(logger, validation, problems, warnings)
+	if err != nil {
+		return packit.Process{}, err
+	}
+
+	script := fmt.Sprintf(StartupScript, startCommand(target, workingDir))
+	switch {
+	case target.Supervised:
+		script = fmt.Sprintf(SupervisedStartupScript, startCommand(target, workingDir))
+	case target.RelaySignal != "":
+		script = fmt.Sprintf(RelayStartupScript, strings.TrimPrefix(target.RelaySignal, "SIG"), startCommand(target, workingDir))
+	}
+
+	err = os.WriteFile(target.Script, []byte(script), 0644)
+	if err != nil {
+		return packit.Process{}, err
+	}
+
+	return packit.Process{
+		Type:    target.Type,
+		Command: "sh",
+		Args:    []string{target.Script},
+		Default: target.Default,
+		Direct:  true,
+	}, nil
+}
+
+// startCommand returns the shell command that runs the prestart, start and
+// poststart scripts of target.
+func startCommand(target startTarget, workingDir string) string {
+	arg := concatenateNpmScripts(target.Package)
+
+	// Packages in a workspace also run the binaries hoisted to the root
+	// node_modules, the way npm does.
+	if len(target.BinDirs) > 1 {
+		arg = fmt.Sprintf("export PATH=%s:$PATH && %s", strings.Join(target.BinDirs, string(os.PathListSeparator)), arg)
+	}
+
+	// Ideally we would like the lifecycle to support setting a custom working
+	// directory to run the launch process.  Until that happens we will cd in.
+
+	if target.Path != workingDir {
+		arg = fmt.Sprintf("cd %s && %s", target.Path, arg)
+	}
+
+	/*
+		Ubuntu uses Dash as the default shell, while UBI uses Bash.
+		The version of Bash on the current UBI images does not properly handle
+		the signal handling logic added in the script. Running the command using bash -c
+		and escaping the quotes changes the behavior to match that of of running with Dash.
+		This issue is fixed in more recent versions of Bash (>=5.x), however until UBI and Ubuntu
+		begin using this version, the following workaround is necesary.
+	*/
+	etcOsReleaseFileContent, err := os.ReadFile(filepath.Join("/etc/os-release"))
+	if err == nil {
+		re := regexp.MustCompile(`ID=(rhel|"rhel")`)
+
+		match := re.FindStringSubmatch(string(etcOsReleaseFileContent))
+		if match != nil {
+			arg = fmt.Sprintf("bash -c \"%s\"", strings.ReplaceAll(arg, `"`, `\"`))
+		}
+	}
+
+	return arg
+}
+
+func concatenateNpmScripts(pkg libnodejs.PackageJSON) string {
+	arg := fmt.Sprintf("%s $@", pkg.Scripts.Start)
+
+	if pkg.Scripts.PreStart != "" {
+		arg = fmt.Sprintf("%s && %s", pkg.Scripts.PreStart, arg)
+	}
+
+	if pkg.Scripts.PostStart != "" {
+		arg = fmt.Sprintf("%s && %s", arg, pkg.Scripts.PostStart)
+	}
+
+	return arg
+}
+
+// markDefaultTarget marks the target selected by BP_NPM_START_DEFAULT_PROCESS,
+// given as a process type, package name or path relative to projectPath, as
+// the default process. The first target is the default when it is not set.
+func markDefaultTarget(targets []startTarget, projectPath string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+
+	value := os.Getenv("BP_NPM_START_DEFAULT_PROCESS")
+	if value == "" {
+		targets[0].Default = true
+		return nil
+	}
+
+	var types []string
+	for i, target := range targets {
+		if value == target.Type || value == target.Manifest.Name || filepath.Join(projectPath, value) == target.Path {
+			targets[i].Default = true
+			return nil
+		}
+		types = append(types, target.Type)
+	}
+
+	return fmt.Errorf("failed to parse BP_NPM_START_DEFAULT_PROCESS value %s: must be one of %s", value, strings.Join(types, ", "))
+}
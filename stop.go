@@ -0,0 +1,91 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+)
+
+// DefaultShutdownTimeout leaves a margin within the 10 second grace period
+// that container runtimes give a container before killing it.
+const DefaultShutdownTimeout = 8 * time.Second
+
+// stopSettings parses BP_NPM_START_STOP_ORDER and
+// BP_NPM_START_SHUTDOWN_TIMEOUT.
+func stopSettings() (string, time.Duration, error) {
+	order := os.Getenv("BP_NPM_START_STOP_ORDER")
+	switch order {
+	case "":
+		order = launcher.StopBefore
+	case launcher.StopBefore, launcher.StopAfter, launcher.StopParallel:
+	default:
+		return "", 0, fmt.Errorf("failed to parse BP_NPM_START_STOP_ORDER value %s: must be one of %s, %s or %s", order, launcher.StopBefore, launcher.StopAfter, launcher.StopParallel)
+	}
+
+	timeout := DefaultShutdownTimeout
+	if value := os.Getenv("BP_NPM_START_SHUTDOWN_TIMEOUT"); value != "" {
+		var err error
+		timeout, err = time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return "", 0, fmt.Errorf("failed to parse BP_NPM_START_SHUTDOWN_TIMEOUT value %s: must be a positive duration such as 8s", value)
+		}
+	}
+
+	return order, timeout, nil
+}
+
+// stopOrderDescription describes when the stop script runs relative to
+// signalling the start process.
+func stopOrderDescription(order string) string {
+	switch order {
+	case launcher.StopAfter:
+		return "after the process has exited"
+	case launcher.StopParallel:
+		return "while the process is stopping"
+	default:
+		return "before the process is signalled"
+	}
+}
+
+// shouldRunStopScript reports whether BP_NPM_START_STOP_SCRIPT, which
+// defaults to false, is enabled.
+func shouldRunStopScript() (bool, error) {
+	value, ok := os.LookupEnv("BP_NPM_START_STOP_SCRIPT")
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	run, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse BP_NPM_START_STOP_SCRIPT value %s: %w", value, err)
+	}
+
+	return run, nil
+}
+
+func hasStopScript(target startTarget) bool {
+	return target.Manifest.Scripts["stop"] != ""
+}
+
+// stopHook writes a script that runs the prestop, stop and poststop scripts
+// of target to layerPath and returns the StopHook that runs it, or nil when
+// target has no stop script.
+func stopHook(target startTarget, order string, timeout time.Duration, workingDir, layerPath string) (*launcher.StopHook, error) {
+	if !hasStopScript(target) {
+		return nil, nil
+	}
+
+	command, err := lifecycleScript(target, "stop", target.Manifest.Scripts["prestop"], target.Manifest.Scripts["stop"], target.Manifest.Scripts["poststop"], workingDir, layerPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &launcher.StopHook{
+		Command: command,
+		Order:   order,
+		Timeout: timeout,
+	}, nil
+}
@@ -0,0 +1,286 @@
+package npmstart
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+const (
+	ValidateStrict = "strict"
+	ValidateWarn   = "warn"
+	ValidateOff    = "off"
+)
+
+var (
+	envAssignment = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*=`)
+
+	// shellBuiltins are commands that are not resolved from the filesystem
+	// and therefore cannot be validated.
+	shellBuiltins = []string{
+		".", ":", "[", "alias", "cd", "echo", "eval", "exec", "exit", "export",
+		"false", "kill", "printf", "read", "set", "shift", "source", "test",
+		"trap", "true", "ulimit", "umask", "unset", "wait",
+	}
+
+	// nodeFlagsWithValue are node CLI flags that consume the following
+	// argument, which must not be mistaken for the entrypoint.
+	nodeFlagsWithValue = []string{
+		"-r", "--require", "--import", "--loader", "--experimental-loader",
+		"--conditions", "-C", "--env-file", "--title", "--input-type",
+	}
+
+	// nodeEvalFlags make node run inline code instead of an entrypoint file.
+	nodeEvalFlags = []string{"-e", "--eval", "-p", "--print", "-i", "--interactive", "--test", "-v", "--version", "-h", "--help"}
+)
+
+// validationMode returns the value of BP_NPM_START_VALIDATE, defaulting to
+// warn.
+func validationMode() (string, error) {
+	mode, ok := os.LookupEnv("BP_NPM_START_VALIDATE")
+	if !ok || mode == "" {
+		return ValidateWarn, nil
+	}
+
+	switch mode {
+	case ValidateStrict, ValidateWarn, ValidateOff:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("failed to parse BP_NPM_START_VALIDATE value %s: must be one of %s, %s or %s", mode, ValidateStrict, ValidateWarn, ValidateOff)
+	}
+}
+
+// reportProblems surfaces the given problems according to mode: in strict
+// mode they fail the build, in warn mode they are logged. The warnings, which
+// may not hold at launch, are logged in both modes.
+func reportProblems(logger scribe.Emitter, mode string, problems, warnings []string) error {
+	if mode == ValidateOff {
+		return nil
+	}
+
+	for _, warning := range warnings {
+		logger.Subprocess("Warning: %s", warning)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	if mode == ValidateStrict {
+		return fmt.Errorf("start command validation failed:\n  %s", strings.Join(problems, "\n  "))
+	}
+
+	for _, problem := range problems {
+		logger.Subprocess("Warning: %s", problem)
+	}
+
+	return nil
+}
+
+// validateScript statically checks every command in a shell script, as found
+// in the scripts section of package.json, and returns a description of each
+// entrypoint that will not be runnable at launch. Executables are looked up
+// in binDirs before the PATH. Commands after a cd are checked relative to its
+// directory, and no longer checked when that directory cannot be told.
+func validateScript(script, projectPath string, binDirs []string) ([]string, []string) {
+	var problems, warnings []string
+	dir := projectPath
+	for _, segment := range splitShellCommands(script) {
+		words := trimCommandPrefix(segment)
+		if len(words) > 0 && words[0] == "cd" {
+			if len(words) != 2 || words[1] == "-" || strings.ContainsAny(words[1], "\"'`$*?~") {
+				break
+			}
+
+			if filepath.IsAbs(words[1]) {
+				dir = words[1]
+			} else {
+				dir = filepath.Join(dir, words[1])
+			}
+			continue
+		}
+
+		p, w := validateCommand(segment, dir, binDirs)
+		problems = append(problems, p...)
+		warnings = append(warnings, w...)
+	}
+
+	return problems, warnings
+}
+
+// splitShellCommands splits a script on the shell control operators so that
+// each resulting command can be validated on its own. Quoting is not
+// interpreted; commands containing quotes are skipped by validateCommand.
+func splitShellCommands(script string) [][]string {
+	var (
+		commands [][]string
+		current  []string
+	)
+
+	for _, field := range strings.Fields(script) {
+		switch field {
+		case "&&", "||", ";", "|", "&":
+			commands = append(commands, current)
+			current = nil
+			continue
+		}
+
+		if trimmed := strings.TrimRight(field, ";"); trimmed != field {
+			if trimmed != "" {
+				current = append(current, trimmed)
+			}
+			commands = append(commands, current)
+			current = nil
+			continue
+		}
+
+		current = append(current, field)
+	}
+
+	return append(commands, current)
+}
+
+// validateCommand checks a single command, given as its whitespace-separated
+// words, relative to projectPath. Executables missing from the PATH are
+// returned as warnings rather than problems, since the PATH of the build image
+// is not that of the run image.
+func validateCommand(words []string, projectPath string, binDirs []string) ([]string, []string) {
+	words = trimCommandPrefix(words)
+	if len(words) == 0 {
+		return nil, nil
+	}
+
+	for _, word := range words {
+		if strings.ContainsAny(word, "\"'`$*?") {
+			return nil, nil
+		}
+	}
+
+	executable := words[0]
+	switch {
+	case executable == "node" || executable == "nodejs":
+		return validateNodeEntrypoint(words[1:], projectPath), nil
+
+	case filepath.IsAbs(executable):
+		return nil, nil
+
+	case strings.Contains(executable, "/"):
+		return validateExecutableFile(executable, filepath.Join(projectPath, executable)), nil
+
+	case slices.Contains(shellBuiltins, executable):
+		return nil, nil
+	}
+
+	for _, dir := range binDirs {
+		binPath := filepath.Join(dir, executable)
+		if _, err := os.Lstat(binPath); err == nil {
+			if _, err := os.Stat(binPath); err != nil {
+				return []string{fmt.Sprintf("executable %q is a broken link in node_modules/.bin", executable)}, nil
+			}
+			return nil, nil
+		}
+	}
+
+	if _, err := exec.LookPath(executable); err != nil {
+		return nil, []string{fmt.Sprintf("executable %q was not found in node_modules/.bin or on the PATH of the build image", executable)}
+	}
+
+	return nil, nil
+}
+
+// trimCommandPrefix drops leading variable assignments and the env and exec
+// wrappers so that the first word is the executable being run.
+func trimCommandPrefix(words []string) []string {
+	for len(words) > 0 && (envAssignment.MatchString(words[0]) || words[0] == "env" || words[0] == "exec") {
+		words = words[1:]
+	}
+
+	return words
+}
+
+func validateNodeEntrypoint(args []string, projectPath string) []string {
+	var entrypoint string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				entrypoint = args[i+1]
+			}
+			break
+		}
+
+		if slices.Contains(nodeEvalFlags, arg) {
+			return nil
+		}
+
+		if slices.Contains(nodeFlagsWithValue, arg) {
+			i++
+			continue
+		}
+
+		if strings.HasPrefix(arg, "-") {
+			continue
+		}
+
+		entrypoint = arg
+		break
+	}
+
+	if entrypoint == "" || entrypoint == "-" {
+		return nil
+	}
+
+	path := entrypoint
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(projectPath, entrypoint)
+	}
+
+	// Mirror the way node resolves a main module: the exact path, the path
+	// with a known extension, or a directory containing an index file.
+	candidates := []string{path, path + ".js", path + ".mjs", path + ".cjs", filepath.Join(path, "index.js")}
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return nil
+		}
+	}
+
+	return []string{fmt.Sprintf("node entrypoint %q does not exist (resolved to %s)", entrypoint, path)}
+}
+
+func validateExecutableFile(executable, path string) []string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return []string{fmt.Sprintf("executable %q does not exist (resolved to %s)", executable, path)}
+	}
+
+	if info.IsDir() {
+		return []string{fmt.Sprintf("executable %q is a directory (resolved to %s)", executable, path)}
+	}
+
+	var problems []string
+	if info.Mode().Perm()&0111 == 0 {
+		problems = append(problems, fmt.Sprintf("executable %q is not executable (mode %s)", executable, info.Mode().Perm()))
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return append(problems, fmt.Sprintf("executable %q cannot be read: %s", executable, err))
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	n, _ := file.Read(header)
+	header = header[:n]
+	if !bytes.HasPrefix(header, []byte("#!")) && !bytes.Equal(header, []byte("\x7fELF")) {
+		problems = append(problems, fmt.Sprintf("executable %q has no shebang line and is not a binary", executable))
+	}
+
+	return problems
+}
@@ -0,0 +1,177 @@
+package npmstart
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/paketo-buildpacks/libnodejs"
+)
+
+// workspace is a package in an npm workspaces monorepo.
+type workspace struct {
+	Name     string
+	Path     string
+	Manifest packageManifest
+}
+
+// workspaces expands the "workspaces" globs of the package.json in rootPath
+// into the packages they match, in the order of the globs. Globs prefixed
+// with "!" exclude the packages they match.
+func workspaces(rootPath string, root packageManifest) ([]workspace, error) {
+	var (
+		paths    []string
+		excluded []string
+	)
+	for _, glob := range root.Workspaces {
+		pattern, exclude := strings.CutPrefix(glob, "!")
+
+		matches, err := filepath.Glob(filepath.Join(rootPath, filepath.Clean(pattern)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand workspaces glob %q: %w", glob, err)
+		}
+
+		if exclude {
+			excluded = append(excluded, matches...)
+			continue
+		}
+		paths = append(paths, matches...)
+	}
+
+	var packages []workspace
+	for _, path := range compact(paths) {
+		if slices.Contains(excluded, path) {
+			continue
+		}
+
+		if _, err := os.Stat(filepath.Join(path, "package.json")); err != nil {
+			continue
+		}
+
+		manifest, err := parsePackageManifest(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse package.json in workspace %s: %w", path, err)
+		}
+
+		packages = append(packages, workspace{
+			Name:     manifest.Name,
+			Path:     path,
+			Manifest: manifest,
+		})
+	}
+
+	return packages, nil
+}
+
+// startWorkspace resolves BP_NPM_START_WORKSPACE, given as either a package
+// name or a path relative to rootPath, to one of the workspaces declared in
+// the package.json in rootPath. It returns an empty workspace when the
+// variable is not set.
+func startWorkspace(rootPath string, root packageManifest) (workspace, error) {
+	value := os.Getenv("BP_NPM_START_WORKSPACE")
+	if value == "" {
+		return workspace{}, nil
+	}
+
+	if len(root.Workspaces) == 0 {
+		return workspace{}, fmt.Errorf("failed to resolve BP_NPM_START_WORKSPACE value %s: %s declares no workspaces", value, filepath.Join(rootPath, "package.json"))
+	}
+
+	packages, err := workspaces(rootPath, root)
+	if err != nil {
+		return workspace{}, err
+	}
+
+	var available []string
+	for _, pkg := range packages {
+		if pkg.Name == value || pkg.Path == filepath.Join(rootPath, value) {
+			return pkg, nil
+		}
+
+		name := pkg.Name
+		if name == "" {
+			name, _ = filepath.Rel(rootPath, pkg.Path)
+		}
+		available = append(available, name)
+	}
+
+	message := fmt.Sprintf("failed to resolve BP_NPM_START_WORKSPACE value %s: no workspace has that name or path", value)
+	if len(available) > 0 {
+		message = fmt.Sprintf("%s; available workspaces: %s", message, strings.Join(available, ", "))
+	}
+
+	return workspace{}, errors.New(message)
+}
+
+// shouldStartWorkspaceProcesses reports whether BP_NPM_START_WORKSPACE_PROCESSES
+// is enabled.
+func shouldStartWorkspaceProcesses() (bool, error) {
+	value, ok := os.LookupEnv("BP_NPM_START_WORKSPACE_PROCESSES")
+	if !ok || value == "" {
+		return false, nil
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse BP_NPM_START_WORKSPACE_PROCESSES value %s: %w", value, err)
+	}
+
+	if enabled && os.Getenv("BP_NPM_START_WORKSPACE") != "" {
+		return false, fmt.Errorf("BP_NPM_START_WORKSPACE_PROCESSES cannot be used with BP_NPM_START_WORKSPACE")
+	}
+
+	return enabled, nil
+}
+
+// workspaceTargets returns a start target, named after the package, for every
+// workspace declared in the package.json in rootPath that has a start script.
+// Their StartupScripts are written to scriptDir.
+func workspaceTargets(rootPath string, root packageManifest, scriptDir string) ([]startTarget, error) {
+	packages, err := workspaces(rootPath, root)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []startTarget
+	paths := map[string]string{}
+	for _, ws := range packages {
+		pkg, err := libnodejs.ParsePackageJSON(ws.Path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !pkg.HasStartScript() {
+			continue
+		}
+
+		name := ws.Name
+		if name == "" {
+			name = filepath.Base(ws.Path)
+		}
+
+		processType := processTypeName(name)
+		if other, ok := paths[processType]; ok {
+			return nil, fmt.Errorf("workspaces %s and %s both map to process type %q", other, ws.Path, processType)
+		}
+		paths[processType] = ws.Path
+
+		targets = append(targets, startTarget{
+			Type:       processType,
+			Path:       ws.Path,
+			Package:    pkg,
+			Manifest:   ws.Manifest,
+			ScriptName: startScriptName(),
+			BinDirs: []string{
+				filepath.Join(ws.Path, "node_modules", ".bin"),
+				filepath.Join(rootPath, "node_modules", ".bin"),
+			},
+			Script: filepath.Join(scriptDir, fmt.Sprintf("%s.sh", processType)),
+		})
+	}
+
+	return targets, nil
+}
@@ -0,0 +1,152 @@
+package npmstart
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/paketo-buildpacks/npm-start/launcher"
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// LauncherBinary is the path, relative to the npm-start layer, that the
+// launcher is copied to.
+var LauncherBinary = filepath.Join("bin", "launcher")
+
+const (
+	DefaultRestartMaxRetries = 5
+	DefaultRestartBackoff    = time.Second
+	DefaultWaitTimeout       = time.Minute
+)
+
+// launcherConfig returns the launcher settings configured at build time, and
+// whether any of them require the start process to run under the launcher.
+func launcherConfig() (launcher.Config, bool, error) {
+	var config launcher.Config
+
+	restart, err := restartPolicy()
+	if err != nil {
+		return launcher.Config{}, false, err
+	}
+	config.Restart = restart
+
+	config.WaitFor, err = waitFor()
+	if err != nil {
+		return launcher.Config{}, false, err
+	}
+
+	switch format := os.Getenv("BP_NPM_START_LOG_FORMAT"); format {
+	case "", launcher.LogFormatText:
+	case launcher.LogFormatJSON:
+		config.LogFormat = format
+	default:
+		return launcher.Config{}, false, fmt.Errorf("failed to parse BP_NPM_START_LOG_FORMAT value %s: must be one of %s or %s", format, launcher.LogFormatText, launcher.LogFormatJSON)
+	}
+
+	return config, restart.Policy != "" || config.WaitFor != nil || config.LogFormat != "", nil
+}
+
+// waitFor parses BP_NPM_START_WAIT_FOR and BP_NPM_START_WAIT_TIMEOUT. It
+// returns nil when there are no endpoints to wait for.
+func waitFor() (*launcher.WaitFor, error) {
+	endpoints := splitList(os.Getenv("BP_NPM_START_WAIT_FOR"))
+	if len(endpoints) == 0 {
+		return nil, nil
+	}
+
+	for _, endpoint := range endpoints {
+		if _, err := launcher.ParseEndpoint(endpoint); err != nil {
+			return nil, fmt.Errorf("failed to parse BP_NPM_START_WAIT_FOR value %s: %w", os.Getenv("BP_NPM_START_WAIT_FOR"), err)
+		}
+	}
+
+	wait := &launcher.WaitFor{
+		Endpoints: endpoints,
+		Timeout:   DefaultWaitTimeout,
+	}
+
+	if value := os.Getenv("BP_NPM_START_WAIT_TIMEOUT"); value != "" {
+		timeout, err := time.ParseDuration(value)
+		if err != nil || timeout <= 0 {
+			return nil, fmt.Errorf("failed to parse BP_NPM_START_WAIT_TIMEOUT value %s: must be a positive duration such as 60s", value)
+		}
+		wait.Timeout = timeout
+	}
+
+	return wait, nil
+}
+
+// restartPolicy parses BP_NPM_START_RESTART, BP_NPM_START_RESTART_MAX_RETRIES
+// and BP_NPM_START_RESTART_BACKOFF. Without a restart policy, the zero
+// RestartPolicy is returned.
+func restartPolicy() (launcher.RestartPolicy, error) {
+	policy := launcher.RestartPolicy{
+		MaxRetries: DefaultRestartMaxRetries,
+		Backoff:    DefaultRestartBackoff,
+	}
+
+	switch value := os.Getenv("BP_NPM_START_RESTART"); value {
+	case "", launcher.RestartNo:
+		return launcher.RestartPolicy{}, nil
+	case launcher.RestartOnFailure, launcher.RestartAlways:
+		policy.Policy = value
+	default:
+		return launcher.RestartPolicy{}, fmt.Errorf("failed to parse BP_NPM_START_RESTART value %s: must be one of %s, %s or %s", value, launcher.RestartNo, launcher.RestartOnFailure, launcher.RestartAlways)
+	}
+
+	if value := os.Getenv("BP_NPM_START_RESTART_MAX_RETRIES"); value != "" {
+		retries, err := strconv.Atoi(value)
+		if err != nil || retries < 0 {
+			return launcher.RestartPolicy{}, fmt.Errorf("failed to parse BP_NPM_START_RESTART_MAX_RETRIES value %s: must be a non-negative integer", value)
+		}
+		policy.MaxRetries = retries
+	}
+
+	if value := os.Getenv("BP_NPM_START_RESTART_BACKOFF"); value != "" {
+		backoff, err := time.ParseDuration(value)
+		if err != nil || backoff <= 0 {
+			return launcher.RestartPolicy{}, fmt.Errorf("failed to parse BP_NPM_START_RESTART_BACKOFF value %s: must be a positive duration such as 1s", value)
+		}
+		policy.Backoff = backoff
+	}
+
+	return policy, nil
+}
+
+// launcherProcess writes config, with the given process, which runs the named
+// npm script, as its only child, to layerPath and returns a process that runs
+// it under the launcher. The pre and post hooks, when set, run around the
+// child.
+func launcherProcess(process packit.Process, script string, pre, post *launcher.Hook, config launcher.Config, layerPath string) (packit.Process, error) {
+	config.Children = []launcher.Child{
+		{
+			Name:    process.Type,
+			Script:  script,
+			Command: append([]string{process.Command}, process.Args...),
+			Pre:     pre,
+			Post:    post,
+		},
+	}
+
+	return writeLauncherProcess(process.Type, process.Default, config, layerPath)
+}
+
+// writeLauncherProcess writes config to layerPath and returns a process of
+// the given type that runs it under the launcher.
+func writeLauncherProcess(processType string, isDefault bool, config launcher.Config, layerPath string) (packit.Process, error) {
+	path := filepath.Join(layerPath, fmt.Sprintf("%s.json", processType))
+	err := launcher.Write(path, config)
+	if err != nil {
+		return packit.Process{}, err
+	}
+
+	return packit.Process{
+		Type:    processType,
+		Command: filepath.Join(layerPath, LauncherBinary),
+		Args:    []string{path},
+		Default: isDefault,
+		Direct:  true,
+	}, nil
+}